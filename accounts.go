@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
+	"github.com/obscuren/secp256k1-go"
+	"os"
+	"time"
+)
+
+// Generates a new key pair and adds it to the key ring under the given label.
+// Deterministic wallets derive the next key from their seed instead.
+func CreateAccount(p *Prompter, keyRing *ethkeys.KeyRing, label string) (*ethkeys.Account, error) {
+	if keyRing.IsHD() {
+		pass, err := p.ReadPassphrase("Wallet passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+
+		return keyRing.DeriveAccount(label, pass)
+	}
+
+	pub, prv := secp256k1.GenerateKeyPair()
+	pair := &ethutil.Key{PrivateKey: prv, PublicKey: pub}
+
+	p.Printf("Creating account '%s'. Choose a passphrase to encrypt it with.\n", label)
+	pass, err := p.ReadNewPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	acc, err := ethkeys.NewAccount(label, pair, pass)
+	if err != nil {
+		return nil, err
+	}
+
+	return acc, keyRing.Add(acc)
+}
+
+// Removes the account from the key ring. The passphrase is asked for first
+// so an account can't be thrown away by accident.
+func RemoveAccount(p *Prompter, keyRing *ethkeys.KeyRing, query string) error {
+	acc, err := keyRing.Find(query)
+	if err != nil {
+		return err
+	}
+
+	if _, err := unlockAccount(p, acc); err != nil {
+		return err
+	}
+
+	return keyRing.Remove(acc)
+}
+
+// Asks for the account's passphrase and returns the decrypted key
+func UnlockAccount(acc *ethkeys.Account) (*ethutil.Key, error) {
+	return unlockAccount(Prompt, acc)
+}
+
+func unlockAccount(p *Prompter, acc *ethkeys.Account) (*ethutil.Key, error) {
+	pass, err := p.ReadPassphrase(fmt.Sprintf("Passphrase for '%s': ", acc.Label))
+	if err != nil {
+		return nil, err
+	}
+
+	return acc.Key(pass)
+}
+
+// Unlocks the account for the given number of seconds, or for a single
+// signature if seconds is zero
+func UnlockFor(p *Prompter, keyRing *ethkeys.KeyRing, query string, seconds int) (*ethkeys.Account, error) {
+	if seconds < 0 {
+		return nil, fmt.Errorf("invalid duration %d", seconds)
+	}
+
+	acc, err := keyRing.Find(query)
+	if err != nil {
+		return nil, err
+	}
+
+	pass, err := p.ReadPassphrase(fmt.Sprintf("Passphrase for '%s': ", acc.Label))
+	if err != nil {
+		return nil, err
+	}
+
+	return acc, keyRing.Unlock(acc, pass, time.Duration(seconds)*time.Second)
+}
+
+func PrintAccounts(p *Prompter, keyRing *ethkeys.KeyRing) {
+	if accounts := NewAccountResults(keyRing); len(accounts) > 0 {
+		p.Println(accounts)
+	}
+}
+
+// Turns the key ring into a deterministic wallet. The mnemonic is only shown
+// once, it's the one and only backup of the derived accounts.
+func CreateHDWallet(keyRing *ethkeys.KeyRing) error {
+	mnemonic, err := ethkeys.NewMnemonic()
+	if err != nil {
+		return err
+	}
+
+	seed, err := ethkeys.SeedFromMnemonic(mnemonic)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(`
+Write down the following words and keep them somewhere safe. They
+restore every account ever derived from this wallet.
+
+++++++++++++++++ Mnemonic ++++++++++++++++++
+%s
+++++++++++++++++++++++++++++++++++++++++++++
+
+Choose a passphrase to encrypt the wallet with.
+`, mnemonic)
+
+	return setupHDWallet(keyRing, seed, 1)
+}
+
+// Restores a deterministic wallet from its mnemonic, deriving the first count
+// accounts.
+func RestoreHDWallet(keyRing *ethkeys.KeyRing, count int) error {
+	mnemonic, err := ReadLine("Mnemonic: ")
+	if err != nil {
+		return err
+	}
+
+	seed, err := ethkeys.SeedFromMnemonic(mnemonic)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Choose a passphrase to encrypt the wallet with.")
+
+	return setupHDWallet(keyRing, seed, count)
+}
+
+func setupHDWallet(keyRing *ethkeys.KeyRing, seed []byte, count int) error {
+	pass, err := ReadNewPassphrase()
+	if err != nil {
+		return err
+	}
+
+	if err := keyRing.SetSeed(seed, pass); err != nil {
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		acc, err := keyRing.DeriveAccount("", pass)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%x %s\n", acc.Address, acc.Label)
+	}
+
+	return nil
+}
+
+// Writes the account to a key file. The key is exported encrypted as it's
+// stored in the key ring.
+func ExportKeyFile(acc *ethkeys.Account, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		if !Confirm(fmt.Sprintf("%s already exists. Overwrite?", path)) {
+			return ErrAborted
+		}
+	}
+
+	return ethkeys.NewKeyFile(acc).Write(path)
+}
+
+// Adds the account stored in the key file to the key ring. The key is
+// decrypted first to make sure it's valid and the passphrase is known.
+func ImportKeyFile(keyRing *ethkeys.KeyRing, path string) (*ethkeys.Account, error) {
+	file, err := ethkeys.ReadKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var acc *ethkeys.Account
+	if file.IsEncrypted() {
+		acc, err = file.Account()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := UnlockAccount(acc); err != nil {
+			return nil, err
+		}
+	} else {
+		key, err := file.Key()
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Println("The key file isn't encrypted. Choose a passphrase to encrypt it with.")
+		pass, err := ReadNewPassphrase()
+		if err != nil {
+			return nil, err
+		}
+
+		acc, err = ethkeys.NewAccount(file.Label, key, pass)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := keyRing.Find(acc.Hex())
+	replace := err == nil
+	if replace && !Confirm(fmt.Sprintf("Account '%s' %x already exists. Overwrite?", existing.Label, existing.Address)) {
+		return nil, ErrAborted
+	}
+
+	if len(acc.Label) == 0 {
+		acc.Label = "imported"
+	}
+
+	if other, err := keyRing.Find(acc.Label); err == nil && bytes.Compare(other.Address, acc.Address) != 0 {
+		acc.Label = fmt.Sprintf("%s-%x", acc.Label, acc.Address[:4])
+	}
+
+	// The existing account stays until the new one is in place
+	if replace {
+		return acc, keyRing.Replace(acc)
+	}
+
+	return acc, keyRing.Add(acc)
+}
+
+// Signs the message with the key and prints the hex encoded signature
+func SignMessage(p *Prompter, key *ethutil.Key, msg string) error {
+	sig, err := ethkeys.SignMessage(key, []byte(msg))
+	if err != nil {
+		return err
+	}
+	p.Printf("%x\n", sig)
+
+	return nil
+}
+
+// Recovers the signer of the message. When an address is given the signature
+// is only valid if it was made by that address, the signer is returned along
+// with ErrSignerMismatch otherwise.
+func VerifyMessage(addr, sig, msg string) ([]byte, error) {
+	sigBytes, err := hex.DecodeString(sig)
+	if err != nil {
+		return nil, fmt.Errorf("signature err: %v", err)
+	}
+
+	signer, err := ethkeys.RecoverMessageSigner([]byte(msg), sigBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addr) > 0 {
+		expected, err := hex.DecodeString(addr)
+		if err != nil {
+			return signer, fmt.Errorf("address err: %v", err)
+		}
+
+		if bytes.Compare(expected, signer) != 0 {
+			return signer, ethkeys.ErrSignerMismatch
+		}
+	}
+
+	return signer, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+// Running nodes listen on a Unix socket in the data dir. Every connection is
+// a console session of its own, `-attach` connects the terminal to one. The
+// socket lives in a directory only the owner can enter, so it's never
+// reachable by others, not even before its own permissions are set.
+const (
+	consoleDir    = "console"
+	consoleSocket = "console.ipc"
+)
+
+// Attached terminals echo whatever is typed, passphrases are only entered on
+// the node itself
+var ErrAttachedPassphrase = errors.New("passphrases can't be entered in attached sessions, use the node's console")
+
+func ConsoleSocketPath() string {
+	return path.Join(ethutil.Config.ExecPath, consoleDir, consoleSocket)
+}
+
+type ConsoleServer struct {
+	listener  net.Listener
+	ethereum  *eth.Ethereum
+	keyRing   *ethkeys.KeyRing
+	watchList *ethkeys.WatchList
+
+	mut      sync.Mutex
+	sessions map[net.Conn]bool
+}
+
+// Listens on the console socket. A socket left behind by a node which didn't
+// shut down cleanly is replaced, one in use is an error.
+func StartConsoleServer(ethereum *eth.Ethereum, keyRing *ethkeys.KeyRing, watchList *ethkeys.WatchList) (*ConsoleServer, error) {
+	path := ConsoleSocketPath()
+	dir := filepath.Dir(path)
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	// The directory may have been created with other permissions
+	if err := os.Chmod(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s is in use by another node", path)
+	}
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sessions can sign with unlocked accounts, only the owner may connect
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	s := &ConsoleServer{
+		listener:  listener,
+		ethereum:  ethereum,
+		keyRing:   keyRing,
+		watchList: watchList,
+		sessions:  make(map[net.Conn]bool),
+	}
+	go s.accept()
+
+	return s, nil
+}
+
+func (s *ConsoleServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			// The listener is closed by Stop
+			return
+		}
+
+		s.mut.Lock()
+		s.sessions[conn] = true
+		s.mut.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+func (s *ConsoleServer) serve(conn net.Conn) {
+	defer func() {
+		s.mut.Lock()
+		delete(s.sessions, conn)
+		s.mut.Unlock()
+
+		conn.Close()
+	}()
+
+	// Confirmations work as they do for the node. Passphrases are refused,
+	// -passfile included, accounts unlocked on the node can still be used.
+	prompt := NewPrompter(conn, conn)
+	prompt.AssumeYes = Prompt.AssumeYes
+	prompt.PassphraseErr = ErrAttachedPassphrase
+
+	console := NewConsole(s.ethereum, s.keyRing, s.watchList, prompt)
+	prompt.Println("Eth Console (attached). Type (help) for help")
+	for {
+		str, err := prompt.readLine("eth >>> ")
+		if err != nil || !console.ParseInput(str) {
+			return
+		}
+	}
+}
+
+// Closes the socket and ends the sessions
+func (s *ConsoleServer) Stop() {
+	s.listener.Close()
+	os.Remove(ConsoleSocketPath())
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for conn := range s.sessions {
+		conn.Close()
+	}
+}
+
+// Connects the terminal to a console session of the node running on the data
+// dir. Returns once either side ends the session.
+func Attach() error {
+	conn, err := net.Dial("unix", ConsoleSocketPath())
+	if err != nil {
+		return fmt.Errorf("no node running on %s (%v)", ethutil.Config.ExecPath, err)
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	go func() {
+		io.Copy(os.Stdout, conn)
+		close(done)
+	}()
+
+	go func() {
+		io.Copy(conn, os.Stdin)
+		// Let the node end the session, its remaining output is still shown
+		conn.(*net.UnixConn).CloseWrite()
+	}()
+
+	<-done
+
+	return nil
+}
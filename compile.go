@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethutil"
+	"io/ioutil"
+	"math/big"
+	"strings"
+)
+
+// Contract sources hold one instruction per line, an opcode mnemonic, a
+// number or any other word which is taken as raw bytes. Empty lines and lines
+// starting with # are skipped.
+const sourceComment = "#"
+
+// A compiled instruction along with where it came from
+type Instruction struct {
+	Line   int    `json:"line"`
+	Source string `json:"source"`
+	Code   string `json:"code"`
+}
+
+// The code compiled from a source. Code is the data contracts are created
+// with, Hex its RLP encoding as it appears in the transaction.
+type Compiled struct {
+	Code    []string       `json:"-"`
+	Hex     string         `json:"code"`
+	Listing []*Instruction `json:"listing"`
+}
+
+// Line numbered compile errors of a source
+type CompileErrors []string
+
+func (e CompileErrors) Error() string {
+	return strings.Join(e, "\n")
+}
+
+func CompileFile(path string) (*Compiled, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := CompileSource(strings.Split(string(src), "\n"))
+	if errs, ok := err.(CompileErrors); ok {
+		for i := range errs {
+			errs[i] = path + ":" + errs[i]
+		}
+	}
+
+	return compiled, err
+}
+
+// Compiles the source lines like ethchain.Compile does. Words which look
+// like mnemonics but aren't opcodes and numbers which don't fit in 256 bits
+// are reported rather than compiled to raw bytes.
+func CompileSource(lines []string) (*Compiled, error) {
+	compiled := &Compiled{}
+
+	var errs CompileErrors
+	for n, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, sourceComment) {
+			continue
+		}
+
+		if err := checkInstruction(line); err != nil {
+			errs = append(errs, fmt.Sprintf("%d: %v", n+1, err))
+			continue
+		}
+
+		code := ethchain.Compile([]string{line})[0]
+		compiled.Code = append(compiled.Code, code)
+		compiled.Listing = append(compiled.Listing, &Instruction{Line: n + 1, Source: line, Code: ethutil.Hex([]byte(code))})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if len(compiled.Code) == 0 {
+		return nil, errors.New("no instructions")
+	}
+
+	data := make([]interface{}, len(compiled.Code))
+	for i, code := range compiled.Code {
+		data[i] = code
+	}
+	compiled.Hex = ethutil.Hex(ethutil.Encode(data))
+
+	return compiled, nil
+}
+
+func checkInstruction(instr string) error {
+	if strings.ContainsAny(instr, " \t") {
+		return fmt.Errorf("one instruction per line expected, got '%s'", instr)
+	}
+
+	if ethchain.IsOpCode(instr) {
+		return nil
+	}
+
+	if num, ok := new(big.Int).SetString(instr, 0); ok {
+		if num.Sign() < 0 || num.BitLen() > 256 {
+			return fmt.Errorf("number out of range: %s", instr)
+		}
+
+		return nil
+	}
+
+	if strings.ToUpper(instr) == instr && strings.Trim(instr, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") == "" {
+		return fmt.Errorf("unknown opcode '%s'", instr)
+	}
+
+	return nil
+}
+
+func (c *Compiled) String() string {
+	var lines []string
+	for i, instr := range c.Listing {
+		lines = append(lines, fmt.Sprintf("%04d %-16s %-10s (line %d)", i, instr.Code, instr.Source, instr.Line))
+	}
+	lines = append(lines, "code "+c.Hex)
+
+	return strings.Join(lines, "\n")
+}
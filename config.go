@@ -17,9 +17,43 @@ var ImportKey string
 var ExportKey bool
 var UseGui bool
 var DataDir string
+var UseAccount string
+var ListAccounts bool
+var AddAccount string
+var DelAccount string
+var Coinbase string
+var CreateHD bool
+var RestoreHD bool
+var HDAccounts int
+var ExportFile string
+var ImportFile string
+var AssumeYes bool
+var NoPrompt bool
+var PassFile string
+var SweepOld bool
+var VanityPrefix string
+var VanitySuffix string
+var VanityFile string
+var SignTx string
+var TxTo string
+var TxValue string
+var TxNonce int
+var TxData string
+var TxFormat string
+var SendTx string
+var SignMsg string
+var VerifyMsg string
+var MsgSig string
+var MsgSigner string
+var Script string
+var KeepGoing bool
+var StartJSConsole bool
+var AttachConsole bool
 
 func Init() {
 	flag.BoolVar(&StartConsole, "c", false, "debug and testing console")
+	flag.BoolVar(&AttachConsole, "attach", false, "opens a console on the node running on the data directory")
+	flag.BoolVar(&StartJSConsole, "js", false, "JavaScript console, .js files given as arguments are loaded first")
 	flag.BoolVar(&StartMining, "m", false, "start dagger mining")
 	flag.BoolVar(&ShowGenesis, "g", false, "prints genesis header and exits")
 	flag.BoolVar(&UseGui, "gui", true, "use the gui")
@@ -30,6 +64,36 @@ func Init() {
 	flag.StringVar(&OutboundPort, "p", "30303", "listening port")
 	flag.StringVar(&DataDir, "dir", ".ethereum", "ethereum data directory")
 	flag.StringVar(&ImportKey, "import", "", "imports the given private key (hex)")
+	flag.StringVar(&UseAccount, "account", "", "sets the default account (label, address or index)")
+	flag.BoolVar(&ListAccounts, "accounts", false, "lists the accounts in the key ring")
+	flag.StringVar(&AddAccount, "newaccount", "", "creates a new account with the given label")
+	flag.StringVar(&DelAccount, "rmaccount", "", "removes the given account from the key ring")
+	flag.StringVar(&Coinbase, "coinbase", "", "account receiving the mining rewards (= default account)")
+	flag.BoolVar(&CreateHD, "hd", false, "creates a deterministic wallet and prints its mnemonic")
+	flag.BoolVar(&RestoreHD, "restore", false, "restores a deterministic wallet from its mnemonic")
+	flag.IntVar(&HDAccounts, "hdaccounts", 1, "number of accounts to derive when restoring a wallet")
+	flag.StringVar(&ExportFile, "exportfile", "", "exports the default account to the given key file")
+	flag.StringVar(&ImportFile, "importfile", "", "imports an account from the given key file")
+	flag.BoolVar(&AssumeYes, "yes", false, "answers yes to every confirmation")
+	flag.BoolVar(&NoPrompt, "no-prompt", false, "never prompts, confirmations are answered with no unless -yes is given")
+	flag.StringVar(&PassFile, "passfile", "", "reads the key passphrase from the first line of the given file")
+	flag.BoolVar(&SweepOld, "sweep", false, "with -genaddr or -import, moves the old account's balance to the new one on the next start")
+	flag.StringVar(&VanityPrefix, "vanity", "", "searches a key whose address starts with the given hex")
+	flag.StringVar(&VanitySuffix, "vanitysuffix", "", "searches a key whose address ends with the given hex")
+	flag.StringVar(&VanityFile, "vanityfile", "", "writes the vanity key to the given key file instead of the key ring, encrypted")
+	flag.StringVar(&SignTx, "signtx", "", "signs a transaction offline and writes it to the given file")
+	flag.StringVar(&TxTo, "txto", "", "recipient of the offline transaction (empty for contracts)")
+	flag.StringVar(&TxValue, "txvalue", "0", "value of the offline transaction (Wei, or e.g. 2.5ether)")
+	flag.IntVar(&TxNonce, "txnonce", -1, "nonce of the offline transaction (required)")
+	flag.StringVar(&TxData, "txdata", "", "file with the code of the offline transaction")
+	flag.StringVar(&TxFormat, "txformat", "json", "format of the offline transaction file (hex, rlp or json)")
+	flag.StringVar(&SendTx, "sendtx", "", "validates the signed transaction file and broadcasts it")
+	flag.StringVar(&SignMsg, "signmsg", "", "signs the message with the default account")
+	flag.StringVar(&VerifyMsg, "verifymsg", "", "verifies the -sig signature of the message and prints its signer")
+	flag.StringVar(&MsgSig, "sig", "", "hex encoded signature checked by -verifymsg")
+	flag.StringVar(&MsgSigner, "signer", "", "address the -verifymsg signature must have been made by")
+	flag.StringVar(&Script, "script", "", "runs the console commands in the given file and exits")
+	flag.BoolVar(&KeepGoing, "keep-going", false, "with -script, keeps running after a command fails")
 	flag.IntVar(&MaxPeer, "x", 5, "maximum desired peers")
 
 	flag.Parse()
@@ -0,0 +1,118 @@
+package ethconsole
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// In text mode results are printed with fmt, nil results print nothing. In
+// JSON mode every command prints a single line holding either
+//
+//	{"result": <result>}
+//
+// or
+//
+//	{"error": "<message>"}
+//
+// Results are encoded with encoding/json, types shared by several commands
+// declare stable field names with json tags.
+const jsonPrefix = "json"
+
+type jsonOutput struct {
+	Result interface{} `json:"result"`
+}
+
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+func (r *Registry) print(asJSON bool, result interface{}, err error) {
+	if !asJSON {
+		if err != nil {
+			fmt.Fprintln(r.Out, err)
+		} else if result != nil {
+			fmt.Fprintln(r.Out, result)
+		}
+
+		return
+	}
+
+	var out interface{} = jsonOutput{result}
+	if err != nil {
+		out = jsonError{err.Error()}
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		data, _ = json.Marshal(jsonError{err.Error()})
+	}
+	fmt.Fprintln(r.Out, string(data))
+}
+
+// A result shown as text in text mode and as its value in JSON mode
+type TextResult struct {
+	Value interface{}
+	Text  string
+}
+
+// Wraps the value so it's printed as the formatted text in text mode
+func Text(value interface{}, format string, v ...interface{}) *TextResult {
+	return &TextResult{Value: value, Text: fmt.Sprintf(format, v...)}
+}
+
+func (t *TextResult) String() string {
+	return t.Text
+}
+
+func (t *TextResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Value)
+}
+
+type CommandHelp struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Usage   string   `json:"usage"`
+	Group   string   `json:"group,omitempty"`
+	Help    string   `json:"help"`
+}
+
+func NewCommandHelp(cmd *Command) *CommandHelp {
+	return &CommandHelp{Name: cmd.Name, Aliases: cmd.Aliases, Usage: cmd.Usage(), Group: cmd.Group, Help: cmd.Help}
+}
+
+func (h *CommandHelp) String() string {
+	str := h.Usage + "\n"
+	if len(h.Aliases) > 0 {
+		str += "Aliases: " + strings.Join(h.Aliases, ", ") + "\n"
+	}
+
+	return str + h.Help
+}
+
+// The help of all commands, listed by group in text mode
+type HelpList []*CommandHelp
+
+func (l HelpList) String() string {
+	var groups []string
+	byGroup := make(map[string][]*CommandHelp)
+	for _, h := range l {
+		if _, ok := byGroup[h.Group]; !ok {
+			groups = append(groups, h.Group)
+		}
+		byGroup[h.Group] = append(byGroup[h.Group], h)
+	}
+
+	str := "COMMANDS:\n"
+	for _, group := range groups {
+		if len(group) > 0 {
+			str += fmt.Sprintf("\033[1m= %s =\033[0m\n", group)
+		}
+
+		for _, h := range byGroup[group] {
+			str += fmt.Sprintf("%s - %s\n", h.Usage, h.Help)
+		}
+	}
+
+	return str + "Type 'help COMMAND' for details"
+}
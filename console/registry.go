@@ -0,0 +1,375 @@
+package ethconsole
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Returned by the quit command, the console stops reading input when a
+// command returns it
+var ErrQuit = errors.New("quit")
+
+type ErrUnknownCommand string
+
+func (e ErrUnknownCommand) Error() string {
+	return fmt.Sprintf("Unknown command: %s", string(e))
+}
+
+// An argument of a command. Optional arguments must follow the required ones
+// and a rest argument can only be the last one.
+type Arg struct {
+	Name     string
+	Optional bool
+	// Takes the remainder of the line, spacing included
+	Rest bool
+	// Returns the values starting with prefix, used for tab completion
+	Complete func(prefix string) []string
+}
+
+// A Command declares everything the console needs to know about it. Arity is
+// checked against Args before Run is called, Run gets one string per given
+// argument. The result of Run is printed in the output mode of the console,
+// see Print.
+type Command struct {
+	Name    string
+	Aliases []string
+	Args    []Arg
+	// Commands of the same group are listed together by help
+	Group string
+	Help  string
+	Run   func(args []string) (interface{}, error)
+}
+
+// Returns the number of arguments the command accepts. Max is -1 for commands
+// with a rest argument.
+func (c *Command) arity() (min, max int) {
+	for _, arg := range c.Args {
+		if arg.Rest {
+			if !arg.Optional {
+				min++
+			}
+			return min, -1
+		}
+
+		if !arg.Optional {
+			min++
+		}
+		max++
+	}
+
+	return min, max
+}
+
+func (c *Command) Usage() string {
+	usage := c.Name
+	for _, arg := range c.Args {
+		name := arg.Name
+		if arg.Rest {
+			name += "..."
+		}
+
+		if arg.Optional {
+			usage += " [" + name + "]"
+		} else {
+			usage += " " + name
+		}
+	}
+
+	return usage
+}
+
+// Splits the line in the command's arguments. The command name must already
+// be stripped.
+func (c *Command) parse(line string) ([]string, error) {
+	words := strings.Fields(line)
+	min, max := c.arity()
+
+	if len(words) < min || max >= 0 && len(words) > max {
+		var expected string
+		switch {
+		case min == max:
+			expected = fmt.Sprintf("%d", min)
+		case max < 0:
+			expected = fmt.Sprintf("at least %d", min)
+		default:
+			expected = fmt.Sprintf("%d to %d", min, max)
+		}
+
+		return nil, fmt.Errorf("'%s' requires %s args, got %d", c.Name, expected, len(words))
+	}
+
+	if max < 0 && len(words) >= len(c.Args) {
+		n := len(c.Args) - 1
+		words = append(words[:n], Rest(line, n))
+	}
+
+	return words, nil
+}
+
+// Returns the line following the first n words. Unlike splitting the line this
+// keeps the original spacing.
+func Rest(line string, n int) string {
+	line = strings.TrimSpace(line)
+	for ; n > 0; n-- {
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			line = strings.TrimLeft(line[i:], " \t")
+		} else {
+			line = ""
+		}
+	}
+
+	return line
+}
+
+// A Registry holds the commands of a console
+type Registry struct {
+	// Results are written to Out
+	Out io.Writer
+	// Prints results and errors as JSON
+	JSON bool
+
+	commands []*Command
+	names    map[string]*Command
+}
+
+// Creates a registry which only knows the help and output commands
+func NewRegistry() *Registry {
+	r := &Registry{Out: os.Stdout, names: make(map[string]*Command)}
+	r.Register(&Command{
+		Name: "help",
+		Args: []Arg{{Name: "COMMAND", Optional: true, Complete: r.completeName}},
+		Help: "Lists the commands or shows the help of a single command",
+		Run: func(args []string) (interface{}, error) {
+			if len(args) == 0 {
+				return r.Help(), nil
+			}
+
+			return r.CommandHelp(args[0])
+		},
+	})
+	r.Register(&Command{
+		Name: "output",
+		Args: []Arg{{Name: "MODE", Optional: true, Complete: func(prefix string) []string {
+			return Filter(prefix, []string{"text", "json"})
+		}}},
+		Help: "Shows or sets the output mode of the session, text or json",
+		Run: func(args []string) (interface{}, error) {
+			if len(args) > 0 {
+				switch args[0] {
+				case "text", "json":
+					r.JSON = args[0] == "json"
+				default:
+					return nil, fmt.Errorf("unknown mode '%s'", args[0])
+				}
+			}
+
+			if r.JSON {
+				return "json", nil
+			}
+
+			return "text", nil
+		},
+	})
+	r.Register(&Command{
+		Name: jsonPrefix,
+		Args: []Arg{{Name: "COMMAND", Rest: true}},
+		Help: "Runs a single command with JSON output",
+		Run: func(args []string) (interface{}, error) {
+			// Lines starting with the prefix are handled by Run
+			return r.Exec(args[0])
+		},
+	})
+
+	return r
+}
+
+// Adds the command. Names and aliases must be unique.
+func (r *Registry) Register(cmd *Command) error {
+	if len(cmd.Name) == 0 || cmd.Run == nil {
+		return errors.New("command requires a name and a handler")
+	}
+
+	for i, arg := range cmd.Args {
+		if arg.Rest && i != len(cmd.Args)-1 {
+			return fmt.Errorf("'%s': only the last argument can be a rest argument", cmd.Name)
+		}
+
+		if i > 0 && cmd.Args[i-1].Optional && !arg.Optional {
+			return fmt.Errorf("'%s': required argument follows an optional one", cmd.Name)
+		}
+	}
+
+	names := append([]string{cmd.Name}, cmd.Aliases...)
+	for _, name := range names {
+		if _, ok := r.names[name]; ok {
+			return fmt.Errorf("command '%s' already exists", name)
+		}
+	}
+
+	for _, name := range names {
+		r.names[name] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+
+	return nil
+}
+
+// Returns the command by name or alias, nil if there's no such command
+func (r *Registry) Lookup(name string) *Command {
+	return r.names[name]
+}
+
+// Returns the commands in the order they were registered
+func (r *Registry) Commands() []*Command {
+	return append([]*Command{}, r.commands...)
+}
+
+// Parses the line and runs the command without printing anything. Empty lines
+// are ignored. Errors of the command are prefixed with its name.
+func (r *Registry) Exec(line string) (interface{}, error) {
+	line = strings.TrimSpace(line)
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	name := strings.Fields(line)[0]
+	cmd := r.Lookup(name)
+	if cmd == nil {
+		return nil, ErrUnknownCommand(name)
+	}
+
+	args, err := cmd.parse(Rest(line, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cmd.Run(args)
+	if err != nil && err != ErrQuit {
+		return nil, fmt.Errorf("%s err: %v", cmd.Name, err)
+	}
+
+	return result, err
+}
+
+// Runs the command on the line and prints its result or error. Lines starting
+// with "json" are printed as JSON whatever the mode of the session.
+func (r *Registry) Run(line string) error {
+	var asJSON bool
+	if fields := strings.Fields(line); len(fields) > 0 && fields[0] == jsonPrefix {
+		asJSON = true
+		line = Rest(line, 1)
+	}
+
+	// The mode is checked afterwards so switching it applies to its own output
+	result, err := r.Exec(line)
+	if err != ErrQuit {
+		r.print(asJSON || r.JSON, result, err)
+	}
+
+	return err
+}
+
+// Prints the result or error in the output mode of the session
+func (r *Registry) Print(result interface{}, err error) {
+	r.print(r.JSON, result, err)
+}
+
+// Returns the help of all commands
+func (r *Registry) Help() HelpList {
+	var help HelpList
+	for _, cmd := range r.commands {
+		help = append(help, NewCommandHelp(cmd))
+	}
+
+	return help
+}
+
+func (r *Registry) CommandHelp(name string) (*CommandHelp, error) {
+	cmd := r.Lookup(name)
+	if cmd == nil {
+		return nil, ErrUnknownCommand(name)
+	}
+
+	return NewCommandHelp(cmd), nil
+}
+
+func (r *Registry) completeName(prefix string) []string {
+	var names []string
+	for name := range r.names {
+		names = append(names, name)
+	}
+
+	return Filter(prefix, names)
+}
+
+// Returns the sorted values starting with prefix, meant for Arg.Complete
+func Filter(prefix string, values []string) []string {
+	var matches []string
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			matches = append(matches, v)
+		}
+	}
+	sort.Strings(matches)
+
+	return matches
+}
+
+// Returns the possible completions of the line. Command names are completed
+// first, arguments are completed by the command's Arg.Complete.
+func (r *Registry) Complete(line string) []string {
+	words := strings.Fields(line)
+	// The word being completed is empty when the line ends in a space
+	if len(line) == 0 || strings.HasSuffix(line, " ") || strings.HasSuffix(line, "\t") {
+		words = append(words, "")
+	}
+
+	if len(words) == 1 {
+		return r.completeName(words[0])
+	}
+
+	// Complete the command following the json prefix
+	if words[0] == jsonPrefix {
+		head := line[:strings.Index(line, jsonPrefix)+len(jsonPrefix)]
+		var lines []string
+		for _, c := range r.Complete(strings.TrimLeft(line[len(head):], " \t")) {
+			lines = append(lines, head+" "+c)
+		}
+
+		return lines
+	}
+
+	cmd := r.Lookup(words[0])
+	n := len(words) - 2
+	if cmd == nil || n >= len(cmd.Args) || cmd.Args[n].Rest || cmd.Args[n].Complete == nil {
+		return nil
+	}
+
+	prefix := words[len(words)-1]
+	head := line[:len(line)-len(prefix)]
+
+	var lines []string
+	for _, c := range cmd.Args[n].Complete(prefix) {
+		lines = append(lines, head+c)
+	}
+
+	return lines
+}
+
+// Commands registered by other packages, added to every console
+var extensions []*Command
+
+// Registers a command with every console created afterwards. Meant to be
+// called from init functions of packages extending the console.
+func Register(cmd *Command) {
+	extensions = append(extensions, cmd)
+}
+
+// Returns the commands registered with Register
+func Extensions() []*Command {
+	return append([]*Command{}, extensions...)
+}
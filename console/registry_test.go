@@ -0,0 +1,168 @@
+package ethconsole
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestRegistry(t *testing.T, got *[]string) *Registry {
+	r := NewRegistry()
+	r.Out = new(bytes.Buffer)
+
+	run := func(args []string) (interface{}, error) {
+		*got = args
+		return args, nil
+	}
+
+	cmds := []*Command{
+		{Name: "tx", Args: []Arg{{Name: "TO"}, {Name: "AMOUNT"}}, Run: run},
+		{Name: "watch", Args: []Arg{{Name: "ADDR", Optional: true}, {Name: "LABEL", Optional: true}}, Run: run},
+		{Name: "signmsg", Args: []Arg{{Name: "MESSAGE", Rest: true}}, Run: run},
+		{
+			Name:    "account",
+			Aliases: []string{"acc"},
+			Args: []Arg{{Name: "ACCOUNT", Complete: func(prefix string) []string {
+				return []string{prefix + "1", prefix + "2"}
+			}}},
+			Run: run,
+		},
+	}
+	for _, cmd := range cmds {
+		if err := r.Register(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return r
+}
+
+func TestRegistryRun(t *testing.T) {
+	var got []string
+	r := newTestRegistry(t, &got)
+
+	tests := []struct {
+		line string
+		args []string
+	}{
+		{"tx abcd 10", []string{"abcd", "10"}},
+		{"watch", nil},
+		{"watch abcd", []string{"abcd"}},
+		{"signmsg  hello   world ", []string{"hello   world"}},
+		{"acc primary", []string{"primary"}},
+	}
+	for _, test := range tests {
+		got = nil
+		if err := r.Run(test.line); err != nil {
+			t.Errorf("%s: %v", test.line, err)
+		}
+
+		if len(got) != len(test.args) || len(got) > 0 && !reflect.DeepEqual(got, test.args) {
+			t.Errorf("%s: expected %q, got %q", test.line, test.args, got)
+		}
+	}
+
+	for _, line := range []string{"tx abcd", "tx a b c", "watch a b c", "signmsg", "account"} {
+		if err := r.Run(line); err == nil {
+			t.Errorf("%s: expected an arity error", line)
+		}
+	}
+
+	if _, err := r.Exec("nope"); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+
+	if _, ok := r.Run("nope").(ErrUnknownCommand); !ok {
+		t.Error("expected ErrUnknownCommand")
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := newTestRegistry(t, new([]string))
+	run := func([]string) (interface{}, error) { return nil, nil }
+
+	if err := r.Register(&Command{Name: "acc", Run: run}); err == nil {
+		t.Error("expected an error registering an existing alias")
+	}
+
+	if err := r.Register(&Command{Name: "x", Args: []Arg{{Name: "A", Rest: true}, {Name: "B"}}, Run: run}); err == nil {
+		t.Error("expected an error for a rest argument which isn't last")
+	}
+
+	if err := r.Register(&Command{Name: "y", Args: []Arg{{Name: "A", Optional: true}, {Name: "B"}}, Run: run}); err == nil {
+		t.Error("expected an error for a required argument after an optional one")
+	}
+}
+
+func TestRegistryComplete(t *testing.T) {
+	r := newTestRegistry(t, new([]string))
+
+	if c := r.Complete("acc"); !reflect.DeepEqual(c, []string{"acc", "account"}) {
+		t.Errorf("unexpected command completion %q", c)
+	}
+
+	if c := r.Complete("account p"); !reflect.DeepEqual(c, []string{"account p1", "account p2"}) {
+		t.Errorf("unexpected argument completion %q", c)
+	}
+
+	if c := r.Complete("help si"); !reflect.DeepEqual(c, []string{"help signmsg"}) {
+		t.Errorf("unexpected help completion %q", c)
+	}
+
+	if c := r.Complete("tx "); len(c) != 0 {
+		t.Errorf("expected no completion, got %q", c)
+	}
+}
+
+func TestRegistryHelp(t *testing.T) {
+	r := newTestRegistry(t, new([]string))
+	if err := r.Run("help"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Run("help acc"); err != nil {
+		t.Fatal(err)
+	}
+
+	out := r.Out.(*bytes.Buffer).String()
+	for _, usage := range []string{"watch [ADDR] [LABEL]", "signmsg MESSAGE...", "Aliases: acc"} {
+		if !strings.Contains(out, usage) {
+			t.Errorf("help doesn't contain '%s'", usage)
+		}
+	}
+}
+
+func TestRegistryJSON(t *testing.T) {
+	r := newTestRegistry(t, new([]string))
+	out := r.Out.(*bytes.Buffer)
+
+	r.Run("json tx abcd 10")
+	if str := out.String(); str != `{"result":["abcd","10"]}`+"\n" {
+		t.Errorf("unexpected result %s", str)
+	}
+
+	out.Reset()
+	r.Run("json tx abcd")
+	if str := out.String(); !strings.HasPrefix(str, `{"error":`) {
+		t.Errorf("expected an error, got %s", str)
+	}
+
+	out.Reset()
+	r.Run("output json")
+	r.Run("watch")
+	if str := out.String(); str != `{"result":"json"}`+"\n"+`{"result":[]}`+"\n" {
+		t.Errorf("unexpected session output %s", str)
+	}
+
+	out.Reset()
+	r.Run("output text")
+	r.Run("acc primary")
+	if str := out.String(); str != "text\n[primary]\n" {
+		t.Errorf("unexpected text output %s", str)
+	}
+
+	if c := r.Complete("json ac"); !reflect.DeepEqual(c, []string{"json acc", "json account"}) {
+		t.Errorf("unexpected json completion %q", c)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/console"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var ErrNoDebugSession = errors.New("no debug session, start one with debug")
+
+// Copies the state of the contract in the current block, code and storage,
+// keyed by the decimal offset like the debugger expects it
+func ContractStorage(ethereum *eth.Ethereum, addr []byte) (map[string]*big.Int, error) {
+	contract := ethereum.BlockManager.BlockChain().CurrentBlock.GetContract(addr)
+	if contract == nil {
+		return nil, fmt.Errorf("no contract at %x", addr)
+	}
+
+	entries, err := TrieEntries(ethutil.Config.Db, contract.State().Root)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := make(map[string]*big.Int)
+	for _, e := range entries {
+		offset := new(big.Int).SetBytes(e.Key)
+		storage[offset.String()] = new(big.Int).SetBytes([]byte(ethutil.NewValueFromBytes(e.Value).Str()))
+	}
+
+	return storage, nil
+}
+
+// Sets up a run of the contract at the address, or of the code compiled from
+// the source file, in a transaction from the default account to it. The
+// transaction runs in the current block.
+func (i *Console) newDebugger(contract string, value *big.Int, data []string) (*Debugger, error) {
+	var storage map[string]*big.Int
+	addr, err := hex.DecodeString(contract)
+	if err == nil && len(addr) == 20 {
+		if storage, err = ContractStorage(i.ethereum, addr); err != nil {
+			return nil, err
+		}
+	} else {
+		compiled, err := CompileFile(contract)
+		if err != nil {
+			return nil, err
+		}
+		storage = CodeStorage(compiled.Code)
+		// A contract which isn't created yet
+		addr = make([]byte, 20)
+	}
+
+	block := i.ethereum.BlockManager.BlockChain().CurrentBlock
+	env := &DebugEnv{
+		Address:    addr,
+		Value:      value,
+		Data:       data,
+		PrevHash:   block.PrevHash,
+		Coinbase:   block.Coinbase,
+		Time:       block.Time,
+		Number:     block.BlockInfo().Number,
+		Difficulty: block.Difficulty,
+		Nonce:      block.Nonce,
+		Balance: func(addr []byte) *big.Int {
+			return i.ethereum.BlockManager.GetAddrState(addr).Account.Amount
+		},
+		Extro: func(addr []byte, offset *big.Int) *big.Int {
+			storage, err := ContractStorage(i.ethereum, addr)
+			if err != nil || storage[offset.String()] == nil {
+				return new(big.Int)
+			}
+
+			return storage[offset.String()]
+		},
+	}
+	if acc := i.keyRing.Default(); acc != nil {
+		env.Sender = acc.Address
+	}
+
+	return NewDebugger(storage, env), nil
+}
+
+func (i *Console) debugSession() (*Debugger, error) {
+	if i.debugger == nil {
+		return nil, ErrNoDebugSession
+	}
+
+	return i.debugger, nil
+}
+
+func parseOffset(arg string) (int, error) {
+	offset, err := strconv.Atoi(arg)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid offset '%s'", arg)
+	}
+
+	return offset, nil
+}
+
+func (i *Console) registerDebugCommands() {
+	i.register([]*ethconsole.Command{
+		{
+			Name:  "debug",
+			Args:  []ethconsole.Arg{{Name: "CONTRACT", Complete: i.completeAddrs}, {Name: "VALUE", Optional: true}, {Name: "DATA", Optional: true, Rest: true}},
+			Group: "Debugger",
+			Help:  "Starts debugging a transaction of VALUE with DATA from the default account to CONTRACT, a contract address or a source file. The transaction runs on a copy of the contract's state in the current block, the chain is never changed.",
+			Run: func(args []string) (interface{}, error) {
+				value := new(big.Int)
+				var rest string
+				if len(args) > 1 {
+					var arg string
+					arg, rest = SplitAmount(args[1], optionalArg(args, 2))
+
+					var err error
+					if value, err = ParseAmount(arg); err != nil {
+						return nil, err
+					}
+				}
+
+				data := []string{}
+				if words := strings.Fields(rest); len(words) > 0 {
+					data = ethchain.Compile(words)
+				}
+
+				debugger, err := i.newDebugger(args[0], value, data)
+				if err != nil {
+					return nil, err
+				}
+				i.debugger = debugger
+
+				return NewDebugResult(debugger), nil
+			},
+		},
+		{
+			Name:    "step",
+			Aliases: []string{"s"},
+			Args:    []ethconsole.Arg{{Name: "COUNT", Optional: true}},
+			Group:   "Debugger",
+			Help:    "Executes the next instruction, or the next COUNT ones",
+			Run: func(args []string) (interface{}, error) {
+				d, err := i.debugSession()
+				if err != nil {
+					return nil, err
+				}
+
+				count := 1
+				if len(args) > 0 {
+					if count, err = strconv.Atoi(args[0]); err != nil || count <= 0 {
+						return nil, fmt.Errorf("invalid count '%s'", args[0])
+					}
+				}
+
+				for n := 0; n < count && !d.Done; n++ {
+					if err := d.Step(); err != nil {
+						return nil, err
+					}
+				}
+
+				return NewDebugResult(d), nil
+			},
+		},
+		{
+			Name:    "continue",
+			Aliases: []string{"c"},
+			Group:   "Debugger",
+			Help:    fmt.Sprintf("Runs until the next breakpoint or the end of the contract, pausing after %d steps", maxDebugSteps),
+			Run: func(args []string) (interface{}, error) {
+				d, err := i.debugSession()
+				if err != nil {
+					return nil, err
+				}
+
+				if err := d.Continue(maxDebugSteps); err != nil {
+					return nil, err
+				}
+
+				return NewDebugResult(d), nil
+			},
+		},
+		{
+			Name:  "break",
+			Args:  []ethconsole.Arg{{Name: "OFFSET", Optional: true}},
+			Group: "Debugger",
+			Help:  "Pauses the run before the instruction at OFFSET, lists the breakpoints without OFFSET",
+			Run: func(args []string) (interface{}, error) {
+				d, err := i.debugSession()
+				if err != nil {
+					return nil, err
+				}
+
+				if len(args) > 0 {
+					offset, err := parseOffset(args[0])
+					if err != nil {
+						return nil, err
+					}
+					d.SetBreakpoint(offset)
+				}
+
+				breakpoints := d.Breakpoints()
+				return ethconsole.Text(breakpoints, "breakpoints %v", breakpoints), nil
+			},
+		},
+		{
+			Name:  "unbreak",
+			Args:  []ethconsole.Arg{{Name: "OFFSET"}},
+			Group: "Debugger",
+			Help:  "Removes the breakpoint at OFFSET",
+			Run: func(args []string) (interface{}, error) {
+				d, err := i.debugSession()
+				if err != nil {
+					return nil, err
+				}
+
+				offset, err := parseOffset(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, d.ClearBreakpoint(offset)
+			},
+		},
+		{
+			Name:  "where",
+			Group: "Debugger",
+			Help:  "Shows the next instruction and the stack",
+			Run: func(args []string) (interface{}, error) {
+				d, err := i.debugSession()
+				if err != nil {
+					return nil, err
+				}
+
+				return NewDebugResult(d), nil
+			},
+		},
+		{
+			Name:  "stack",
+			Group: "Debugger",
+			Help:  "Lists the stack, top first",
+			Run: func(args []string) (interface{}, error) {
+				d, err := i.debugSession()
+				if err != nil {
+					return nil, err
+				}
+
+				values := DebugValues{}
+				for n := len(d.Stack) - 1; n >= 0; n-- {
+					values = append(values, &DebugValue{Offset: fmt.Sprint(len(d.Stack) - 1 - n), Value: d.Stack[n].String()})
+				}
+
+				return values, nil
+			},
+		},
+		{
+			Name:  "memory",
+			Group: "Debugger",
+			Help:  "Lists the memory of the run",
+			Run: func(args []string) (interface{}, error) {
+				d, err := i.debugSession()
+				if err != nil {
+					return nil, err
+				}
+
+				return NewDebugValues(d.Memory, nil), nil
+			},
+		},
+		{
+			Name:  "storage",
+			Group: "Debugger",
+			Help:  "Lists the contract's state, code included, marking what the run wrote",
+			Run: func(args []string) (interface{}, error) {
+				d, err := i.debugSession()
+				if err != nil {
+					return nil, err
+				}
+
+				return NewDebugValues(d.Storage, d.Written), nil
+			},
+		},
+		{
+			Name:  "enddebug",
+			Group: "Debugger",
+			Help:  "Ends the debug session, its state is discarded",
+			Run: func(args []string) (interface{}, error) {
+				if _, err := i.debugSession(); err != nil {
+					return nil, err
+				}
+				i.debugger = nil
+
+				return nil, nil
+			},
+		},
+	})
+}
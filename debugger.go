@@ -0,0 +1,443 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethchain"
+	"math/big"
+	"sort"
+)
+
+// The debugger runs contract code the way the VM does, one instruction at a
+// time, on a copy of the contract's state. Code and storage share that state:
+// the instruction at offset N is the item stored under N, so SSTORE can
+// overwrite code. Fees aren't charged, and instructions which would reach
+// beyond the contract (MKTX, SUICIDE and the crypto instructions) stop the
+// run instead of being simulated. Nothing the debugger does reaches the chain.
+var (
+	ErrDebugDone      = errors.New("the contract has stopped")
+	ErrStackUnderflow = errors.New("stack underflow")
+)
+
+// Number of steps continue takes at most before pausing, contracts may loop
+var maxDebugSteps = 100000
+
+var pow256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), nil)
+
+// The transaction and block the code runs in. Balance and Extro read other
+// accounts for BALANCE and EXTRO and may be nil.
+type DebugEnv struct {
+	Address    []byte
+	Sender     []byte
+	Value      *big.Int
+	Data       []string
+	PrevHash   []byte
+	Coinbase   []byte
+	Time       int64
+	Number     uint64
+	Difficulty *big.Int
+	Nonce      []byte
+
+	Balance func(addr []byte) *big.Int
+	Extro   func(addr []byte, offset *big.Int) *big.Int
+}
+
+type Debugger struct {
+	Env *DebugEnv
+
+	PC    int
+	Stack []*big.Int
+	// Memory and storage are keyed by the decimal offset
+	Memory  map[string]*big.Int
+	Storage map[string]*big.Int
+	Steps   int
+	Done    bool
+
+	ops         map[int64]string
+	written     map[string]bool
+	breakpoints map[int]bool
+}
+
+// Turns code into the storage it's kept in, item by item under its offset
+func CodeStorage(code []string) map[string]*big.Int {
+	storage := make(map[string]*big.Int)
+	for offset, item := range code {
+		if len(item) > 0 {
+			storage[fmt.Sprint(offset)] = new(big.Int).SetBytes([]byte(item))
+		}
+	}
+
+	return storage
+}
+
+// Starts a run at offset 0. The storage is copied, the run never changes it.
+func NewDebugger(storage map[string]*big.Int, env *DebugEnv) *Debugger {
+	d := &Debugger{
+		Env:         env,
+		Memory:      make(map[string]*big.Int),
+		Storage:     make(map[string]*big.Int),
+		ops:         make(map[int64]string),
+		written:     make(map[string]bool),
+		breakpoints: make(map[int]bool),
+	}
+
+	for key, value := range storage {
+		d.Storage[key] = new(big.Int).Set(value)
+	}
+
+	for name, op := range ethchain.OpCodes {
+		d.ops[int64(op)] = name
+	}
+
+	return d
+}
+
+func (d *Debugger) load(offset string) *big.Int {
+	if value, ok := d.Storage[offset]; ok {
+		return new(big.Int).Set(value)
+	}
+
+	return new(big.Int)
+}
+
+// Returns the mnemonic of the instruction at the offset, or the item as a
+// number if it isn't an opcode
+func (d *Debugger) Instruction(offset int) string {
+	item := d.load(fmt.Sprint(offset))
+	if item.BitLen() <= 8 {
+		if name, ok := d.ops[item.Int64()]; ok {
+			return name
+		}
+	}
+
+	return item.String()
+}
+
+// Returns the instruction at PC along with its argument
+func (d *Debugger) Current() string {
+	op := d.Instruction(d.PC)
+	if op == "PUSH" {
+		op += " " + d.load(fmt.Sprint(d.PC+1)).String()
+	}
+
+	return op
+}
+
+func (d *Debugger) push(value *big.Int) {
+	d.Stack = append(d.Stack, value)
+}
+
+func (d *Debugger) pop() (*big.Int, error) {
+	if len(d.Stack) == 0 {
+		return nil, ErrStackUnderflow
+	}
+
+	value := d.Stack[len(d.Stack)-1]
+	d.Stack = d.Stack[:len(d.Stack)-1]
+
+	return value, nil
+}
+
+// Pops the two topmost values, the top one last
+func (d *Debugger) popn() (*big.Int, *big.Int, error) {
+	if len(d.Stack) < 2 {
+		return nil, nil, ErrStackUnderflow
+	}
+
+	x, y := d.Stack[len(d.Stack)-2], d.Stack[len(d.Stack)-1]
+	d.Stack = d.Stack[:len(d.Stack)-2]
+
+	return x, y, nil
+}
+
+func boolInt(b bool) *big.Int {
+	if b {
+		return big.NewInt(1)
+	}
+
+	return new(big.Int)
+}
+
+// Values are unsigned 256 bit numbers, the signed instructions read them as
+// two's complement
+func toSigned(x *big.Int) *big.Int {
+	if x.Bit(255) == 1 {
+		return new(big.Int).Sub(x, pow256)
+	}
+
+	return x
+}
+
+func fromSigned(x *big.Int) *big.Int {
+	return new(big.Int).Mod(x, pow256)
+}
+
+// Executes the instruction at PC. A failing instruction ends the run.
+func (d *Debugger) Step() error {
+	if d.Done {
+		return ErrDebugDone
+	}
+
+	err := d.exec(d.Instruction(d.PC))
+	d.Steps++
+	if err != nil {
+		d.Done = true
+		if err != ErrDebugDone {
+			return fmt.Errorf("%04d %s: %v", d.PC, d.Instruction(d.PC), err)
+		}
+	}
+
+	return nil
+}
+
+func (d *Debugger) exec(op string) error {
+	next := d.PC + 1
+
+	switch op {
+	case "STOP":
+		return ErrDebugDone
+	case "ADD", "MUL", "SUB", "DIV", "SDIV", "MOD", "SMOD", "EXP", "LT", "LE", "GT", "GE", "EQ":
+		x, y, err := d.popn()
+		if err != nil {
+			return err
+		}
+		d.push(arith(op, x, y))
+	case "NEG":
+		x, err := d.pop()
+		if err != nil {
+			return err
+		}
+		d.push(new(big.Int).Mod(new(big.Int).Sub(pow256, x), pow256))
+	case "NOT":
+		x, err := d.pop()
+		if err != nil {
+			return err
+		}
+		d.push(boolInt(x.Sign() == 0))
+	case "MYADDRESS":
+		d.push(new(big.Int).SetBytes(d.Env.Address))
+	case "TXSENDER":
+		d.push(new(big.Int).SetBytes(d.Env.Sender))
+	case "TXVALUE":
+		d.push(new(big.Int).Set(d.Env.Value))
+	case "TXDATAN":
+		d.push(big.NewInt(int64(len(d.Env.Data))))
+	case "TXDATA":
+		x, err := d.pop()
+		if err != nil {
+			return err
+		}
+		if x.Cmp(big.NewInt(int64(len(d.Env.Data)))) < 0 {
+			d.push(new(big.Int).SetBytes([]byte(d.Env.Data[x.Int64()])))
+		} else {
+			d.push(new(big.Int))
+		}
+	case "BLK_PREVHASH":
+		d.push(new(big.Int).SetBytes(d.Env.PrevHash))
+	case "BLK_COINBASE":
+		d.push(new(big.Int).SetBytes(d.Env.Coinbase))
+	case "BLK_TIMESTAMP":
+		d.push(big.NewInt(d.Env.Time))
+	case "BLK_NUMBER":
+		d.push(new(big.Int).SetUint64(d.Env.Number))
+	case "BLK_DIFFICULTY":
+		d.push(new(big.Int).Set(d.Env.Difficulty))
+	case "BLK_NONCE":
+		d.push(new(big.Int).SetBytes(d.Env.Nonce))
+	case "PUSH":
+		d.push(d.load(fmt.Sprint(d.PC + 1)))
+		next++
+	case "POP":
+		if _, err := d.pop(); err != nil {
+			return err
+		}
+	case "DUP":
+		x, err := d.pop()
+		if err != nil {
+			return err
+		}
+		d.push(x)
+		d.push(new(big.Int).Set(x))
+	case "SWAP":
+		x, y, err := d.popn()
+		if err != nil {
+			return err
+		}
+		d.push(y)
+		d.push(x)
+	case "MLOAD":
+		x, err := d.pop()
+		if err != nil {
+			return err
+		}
+		if value, ok := d.Memory[x.String()]; ok {
+			d.push(new(big.Int).Set(value))
+		} else {
+			d.push(new(big.Int))
+		}
+	case "MSTORE":
+		// The offset goes below the value
+		x, y, err := d.popn()
+		if err != nil {
+			return err
+		}
+		d.Memory[x.String()] = y
+	case "SLOAD":
+		x, err := d.pop()
+		if err != nil {
+			return err
+		}
+		d.push(d.load(x.String()))
+	case "SSTORE":
+		// The value goes below the offset
+		y, x, err := d.popn()
+		if err != nil {
+			return err
+		}
+		d.Storage[x.String()] = y
+		d.written[x.String()] = true
+	case "JMP":
+		x, err := d.pop()
+		if err != nil {
+			return err
+		}
+		if next, err = jumpTarget(x); err != nil {
+			return err
+		}
+	case "JMPI":
+		// The destination goes on top of the condition
+		cond, dest, err := d.popn()
+		if err != nil {
+			return err
+		}
+		if cond.Sign() != 0 {
+			if next, err = jumpTarget(dest); err != nil {
+				return err
+			}
+		}
+	case "IND":
+		d.push(big.NewInt(int64(d.PC)))
+	case "BALANCE":
+		x, err := d.pop()
+		if err != nil {
+			return err
+		}
+		if d.Env.Balance == nil {
+			return errors.New("balances aren't available")
+		}
+		d.push(d.Env.Balance(x.Bytes()))
+	case "EXTRO":
+		addr, offset, err := d.popn()
+		if err != nil {
+			return err
+		}
+		if d.Env.Extro == nil {
+			return errors.New("other contracts aren't available")
+		}
+		d.push(d.Env.Extro(addr.Bytes(), offset))
+	default:
+		if _, ok := ethchain.OpCodes[op]; ok {
+			return fmt.Errorf("%s isn't simulated by the debugger", op)
+		}
+		return fmt.Errorf("invalid opcode %s", op)
+	}
+
+	d.PC = next
+
+	return nil
+}
+
+func arith(op string, x, y *big.Int) *big.Int {
+	z := new(big.Int)
+
+	switch op {
+	case "ADD":
+		z.Add(x, y)
+	case "MUL":
+		z.Mul(x, y)
+	case "SUB":
+		z.Sub(x, y)
+	case "DIV", "MOD", "SDIV", "SMOD":
+		// Division by zero gives zero
+		if y.Sign() == 0 {
+			return z
+		}
+		switch op {
+		case "DIV":
+			z.Div(x, y)
+		case "MOD":
+			z.Mod(x, y)
+		case "SDIV":
+			z.Quo(toSigned(x), toSigned(y))
+		case "SMOD":
+			z.Rem(toSigned(x), toSigned(y))
+		}
+	case "EXP":
+		return z.Exp(x, y, pow256)
+	case "LT":
+		return boolInt(x.Cmp(y) < 0)
+	case "LE":
+		return boolInt(x.Cmp(y) <= 0)
+	case "GT":
+		return boolInt(x.Cmp(y) > 0)
+	case "GE":
+		return boolInt(x.Cmp(y) >= 0)
+	case "EQ":
+		return boolInt(x.Cmp(y) == 0)
+	}
+
+	return fromSigned(z)
+}
+
+func jumpTarget(x *big.Int) (int, error) {
+	if x.BitLen() > 31 {
+		return 0, fmt.Errorf("jump out of range: %v", x)
+	}
+
+	return int(x.Int64()), nil
+}
+
+// Steps until the run ends, reaches a breakpoint or has taken max steps. The
+// instruction at PC is executed even if it has a breakpoint, so a paused run
+// can be continued.
+func (d *Debugger) Continue(max int) error {
+	for n := 0; n < max; n++ {
+		if err := d.Step(); err != nil {
+			return err
+		}
+
+		if d.Done || d.breakpoints[d.PC] {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (d *Debugger) SetBreakpoint(offset int) {
+	d.breakpoints[offset] = true
+}
+
+func (d *Debugger) ClearBreakpoint(offset int) error {
+	if !d.breakpoints[offset] {
+		return fmt.Errorf("no breakpoint at %d", offset)
+	}
+	delete(d.breakpoints, offset)
+
+	return nil
+}
+
+func (d *Debugger) Breakpoints() []int {
+	var offsets []int
+	for offset := range d.breakpoints {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+
+	return offsets
+}
+
+// Returns whether the run has written to the offset of the storage
+func (d *Debugger) Written(offset string) bool {
+	return d.written[offset]
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func newTestDebugger(t *testing.T, source string) *Debugger {
+	compiled, err := CompileSource(strings.Fields(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &DebugEnv{Address: []byte{0xaa}, Sender: []byte{0xbb}, Value: big.NewInt(7), Data: []string{"\x05", "\x06"}, Difficulty: new(big.Int)}
+
+	return NewDebugger(CodeStorage(compiled.Code), env)
+}
+
+func expectStack(t *testing.T, d *Debugger, expected ...int64) {
+	if len(d.Stack) != len(expected) {
+		t.Fatalf("expected a stack of %d, got %v", len(expected), d.Stack)
+	}
+
+	for n, value := range expected {
+		if d.Stack[n].Int64() != value {
+			t.Errorf("stack %d: expected %d, got %v", n, value, d.Stack[n])
+		}
+	}
+}
+
+func TestDebuggerStep(t *testing.T) {
+	d := newTestDebugger(t, "PUSH 5 PUSH 3 SUB TXVALUE STOP")
+
+	if op := d.Instruction(d.PC); op != "PUSH" {
+		t.Fatalf("expected PUSH, got %s", op)
+	}
+
+	for n := 0; n < 3; n++ {
+		if err := d.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectStack(t, d, 2)
+
+	if d.PC != 5 {
+		t.Errorf("expected PUSH to skip its argument, pc %d", d.PC)
+	}
+
+	if err := d.Continue(maxDebugSteps); err != nil {
+		t.Fatal(err)
+	}
+	expectStack(t, d, 2, 7)
+
+	if !d.Done || d.Steps != 5 {
+		t.Errorf("expected the run to stop after 5 steps, done %v after %d", d.Done, d.Steps)
+	}
+
+	if err := d.Step(); err != ErrDebugDone {
+		t.Error("expected ErrDebugDone, got", err)
+	}
+}
+
+func TestDebuggerBreakpoints(t *testing.T) {
+	// Counts down from 3, storing the counter at 100 on every pass
+	d := newTestDebugger(t, "PUSH 3 DUP PUSH 100 SSTORE PUSH 1 SUB DUP PUSH 2 JMPI STOP")
+	d.SetBreakpoint(2)
+
+	var counters []int64
+	for !d.Done {
+		if err := d.Continue(maxDebugSteps); err != nil {
+			t.Fatal(err)
+		}
+
+		if !d.Done {
+			if d.PC != 2 {
+				t.Fatalf("expected to pause at 2, pc %d", d.PC)
+			}
+			counters = append(counters, d.Stack[len(d.Stack)-1].Int64())
+		}
+	}
+
+	if len(counters) != 3 || counters[0] != 3 || counters[2] != 1 {
+		t.Errorf("expected to pause with 3, 2 and 1 on the stack, got %v", counters)
+	}
+
+	if value, ok := d.Storage["100"]; !ok || value.Int64() != 1 || !d.Written("100") {
+		t.Errorf("expected 1 stored at 100, got %v", value)
+	}
+
+	if err := d.ClearBreakpoint(2); err != nil || len(d.Breakpoints()) != 0 {
+		t.Error("expected the breakpoint to be cleared", err)
+	}
+
+	if err := d.ClearBreakpoint(2); err == nil {
+		t.Error("expected an error clearing a missing breakpoint")
+	}
+}
+
+func TestDebuggerMemoryAndData(t *testing.T) {
+	d := newTestDebugger(t, "PUSH 1 TXDATA PUSH 9 SWAP MSTORE PUSH 9 MLOAD TXDATAN PUSH 2 TXDATA STOP")
+	if err := d.Continue(maxDebugSteps); err != nil {
+		t.Fatal(err)
+	}
+	expectStack(t, d, 6, 2, 0)
+
+	if value := d.Memory["9"]; value == nil || value.Int64() != 6 {
+		t.Errorf("expected 6 in memory at 9, got %v", value)
+	}
+}
+
+func TestDebuggerCopiesStorage(t *testing.T) {
+	// Overwrites its own first instruction
+	compiled, err := CompileSource(strings.Fields("PUSH 42 PUSH 0 SSTORE STOP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := CodeStorage(compiled.Code)
+
+	d := NewDebugger(storage, &DebugEnv{})
+	if err := d.Continue(maxDebugSteps); err != nil {
+		t.Fatal(err)
+	}
+
+	if value := d.Storage["0"]; value == nil || value.Int64() != 42 {
+		t.Errorf("expected 42 stored at 0, got %v", value)
+	}
+
+	if push := new(big.Int).SetBytes([]byte(compiled.Code[0])); storage["0"].Cmp(push) != 0 {
+		t.Errorf("expected the original storage to stay unchanged, got %v", storage["0"])
+	}
+}
+
+func TestDebuggerErrors(t *testing.T) {
+	d := newTestDebugger(t, "PUSH 1 ADD")
+	if err := d.Continue(maxDebugSteps); err == nil || !strings.Contains(err.Error(), ErrStackUnderflow.Error()) {
+		t.Error("expected a stack underflow, got", err)
+	}
+
+	if !d.Done {
+		t.Error("expected the run to end on an error")
+	}
+
+	d = newTestDebugger(t, "MKTX")
+	if err := d.Step(); err == nil {
+		t.Error("expected MKTX not to be simulated")
+	}
+
+	d = newTestDebugger(t, "PUSH 0 JMP")
+	if err := d.Continue(10); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Done || d.Steps != 10 {
+		t.Errorf("expected the loop to pause after 10 steps, got %d", d.Steps)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/hex"
 	"errors"
@@ -11,243 +10,977 @@ import (
 	"github.com/ethereum/eth-go/ethdb"
 	"github.com/ethereum/eth-go/ethutil"
 	"github.com/ethereum/eth-go/ethwire"
+	"github.com/ethereum/go-ethereum/console"
+	"github.com/ethereum/go-ethereum/keys"
+	"github.com/ethereum/go-ethereum/ui"
+	"github.com/peterh/liner"
+	"io"
+	"io/ioutil"
 	_ "math/big"
+	"net"
 	"os"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+// The console's scratch database. It remembers the keys written to it so its
+// contents can be listed, MemDatabase only prints them to stdout.
+type consoleDb struct {
+	*ethdb.MemDatabase
+
+	keys map[string]bool
+}
+
+func (db *consoleDb) Put(key []byte, value []byte) {
+	db.keys[string(key)] = true
+	db.MemDatabase.Put(key, value)
+}
+
+// Returns the stored key/value pairs ordered by key
+func (db *consoleDb) Entries() []*TrieEntry {
+	var keys []string
+	for key := range db.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries []*TrieEntry
+	for _, key := range keys {
+		if value, _ := db.Get([]byte(key)); len(value) > 0 {
+			entries = append(entries, &TrieEntry{Key: []byte(key), Value: value})
+		}
+	}
+
+	return entries
+}
+
 type Console struct {
-	db       *ethdb.MemDatabase
-	trie     *ethutil.Trie
-	ethereum *eth.Ethereum
+	db        *consoleDb
+	trie      *ethutil.Trie
+	ethereum  *eth.Ethereum
+	keyRing   *ethkeys.KeyRing
+	watchList *ethkeys.WatchList
+	commands  *ethconsole.Registry
+	// Input and output of the session
+	prompt *Prompter
+
+	// Peer addresses connected to with addp, remembered for completion
+	knownPeers map[string]bool
+	// Hash of the last transaction sent from the console
+	lastTx []byte
+	// The contract run stepped through by the debugger commands, if any
+	debugger *Debugger
 }
 
-func NewConsole(s *eth.Ethereum) *Console {
-	db, _ := ethdb.NewMemDatabase()
+func NewConsole(s *eth.Ethereum, keyRing *ethkeys.KeyRing, watchList *ethkeys.WatchList, prompt *Prompter) *Console {
+	mem, _ := ethdb.NewMemDatabase()
+	db := &consoleDb{MemDatabase: mem, keys: make(map[string]bool)}
 	trie := ethutil.NewTrie(db, "")
 
-	return &Console{db: db, trie: trie, ethereum: s}
-}
-
-func (i *Console) ValidateInput(action string, argumentLength int) error {
-	err := false
-	var expArgCount int
-
-	switch {
-	case action == "update" && argumentLength != 2:
-		err = true
-		expArgCount = 2
-	case action == "get" && argumentLength != 1:
-		err = true
-		expArgCount = 1
-	case action == "dag" && argumentLength != 2:
-		err = true
-		expArgCount = 2
-	case action == "decode" && argumentLength != 1:
-		err = true
-		expArgCount = 1
-	case action == "encode" && argumentLength != 1:
-		err = true
-		expArgCount = 1
-	case action == "gettx" && argumentLength != 1:
-		err = true
-		expArgCount = 1
-	case action == "tx" && argumentLength != 2:
-		err = true
-		expArgCount = 2
-	case action == "getaddr" && argumentLength != 1:
-		err = true
-		expArgCount = 1
-	case action == "contract" && argumentLength != 1:
-		err = true
-		expArgCount = 1
-	case action == "say" && argumentLength != 1:
-		err = true
-		expArgCount = 1
-	case action == "addp" && argumentLength != 1:
-		err = true
-		expArgCount = 1
-	case action == "block" && argumentLength != 1:
-		err = true
-		expArgCount = 1
-	}
-
-	if err {
-		return errors.New(fmt.Sprintf("'%s' requires %d args, got %d", action, expArgCount, argumentLength))
-	} else {
-		return nil
-	}
+	console := &Console{db: db, trie: trie, ethereum: s, keyRing: keyRing, watchList: watchList, commands: ethconsole.NewRegistry(), knownPeers: make(map[string]bool), prompt: prompt}
+	console.commands.Out = prompt.out
+	console.registerCommands()
+	console.registerScriptCommands()
+	console.registerExplorerCommands()
+	console.registerDebugCommands()
+	// Commands of other packages come last, they can't replace the built in ones
+	console.register(ethconsole.Extensions())
+
+	return console
 }
 
+// Reads lines until the input ends (Ctrl-D) or a line holds a single dot. The
+// dot ends the editor in attached sessions which can't send an end of file.
 func (i *Console) Editor() []string {
-	var buff bytes.Buffer
+	var lines []string
 	for {
-		reader := bufio.NewReader(os.Stdin)
-		str, _, err := reader.ReadLine()
-		if len(str) > 0 {
-			buff.Write(str)
-			buff.WriteString("\n")
+		str, err := i.prompt.readLine("")
+		if err != nil || str == "." {
+			break
 		}
 
-		if err != nil && err.Error() == "EOF" {
-			break
+		if len(str) > 0 {
+			lines = append(lines, str)
 		}
 	}
 
-	scanner := bufio.NewScanner(strings.NewReader(buff.String()))
-	scanner.Split(bufio.ScanLines)
+	return lines
+}
 
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// Returns the key of the default account, which has to be unlocked first
+func (i *Console) GetKey() (*ethutil.Key, error) {
+	acc := i.keyRing.Default()
+	if acc == nil {
+		return nil, ethkeys.ErrNoKey
 	}
 
-	return lines
+	return i.keyRing.UnlockedKey(acc)
 }
 
-func (i *Console) PrintRoot() {
+// Returns the hex encoded merkle root, or the raw root if it isn't a hash
+func (i *Console) Root() interface{} {
 	root := ethutil.NewValue(i.trie.Root)
 	if len(root.Bytes()) != 0 {
-		fmt.Println(hex.EncodeToString(root.Bytes()))
-	} else {
-		fmt.Println(i.trie.Root)
+		return hex.EncodeToString(root.Bytes())
 	}
+
+	return i.trie.Root
 }
 
-func (i *Console) ParseInput(input string) bool {
-	scanner := bufio.NewScanner(strings.NewReader(input))
-	scanner.Split(bufio.ScanWords)
+// Hex encodes the byte strings of a decoded RLP value, lists keep their shape
+func hexValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return ethutil.Hex(v)
+	case string:
+		return ethutil.Hex([]byte(v))
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for n, item := range v {
+			list[n] = hexValue(item)
+		}
+
+		return list
+	}
+
+	return value
+}
+
+func (i *Console) registerCommands() {
+	commands := []*ethconsole.Command{
+		{
+			Name:  "update",
+			Args:  []ethconsole.Arg{{Name: "KEY"}, {Name: "VALUE"}},
+			Group: "DB",
+			Help:  "Updates/Creates a new value for the given key",
+			Run: func(args []string) (interface{}, error) {
+				i.trie.Update(args[0], args[1])
+
+				return i.Root(), nil
+			},
+		},
+		{
+			Name:  "get",
+			Args:  []ethconsole.Arg{{Name: "KEY"}},
+			Group: "DB",
+			Help:  "Retrieves the given key",
+			Run: func(args []string) (interface{}, error) {
+				return i.trie.Get(args[0]), nil
+			},
+		},
+		{
+			Name:  "root",
+			Group: "DB",
+			Help:  "Prints the hex encoded merkle root",
+			Run: func(args []string) (interface{}, error) {
+				return i.Root(), nil
+			},
+		},
+		{
+			Name:  "rawroot",
+			Group: "DB",
+			Help:  "Prints the raw merkle root, hex encoded. Roots which aren't hashes are RLP encoded first.",
+			Run: func(args []string) (interface{}, error) {
+				raw, ok := i.trie.Root.([]byte)
+				if !ok {
+					raw = ethutil.Encode(i.trie.Root)
+				}
+
+				return ethconsole.Text(ethutil.Hex(raw), "%x", raw), nil
+			},
+		},
+		{
+			Name:  "print",
+			Group: "DB",
+			Help:  "Lists the nodes stored in the console's database by hash",
+			Run: func(args []string) (interface{}, error) {
+				i.trie.Sync()
+
+				return NewTrieDump(i.db.Entries()), nil
+			},
+		},
+		{
+			Name:  "getaddr",
+			Args:  []ethconsole.Arg{{Name: "ADDR", Complete: i.completeAddrs}},
+			Group: "DB",
+			Help:  "Prints the balance and nonce of the address",
+			Run: func(args []string) (interface{}, error) {
+				addr, err := hex.DecodeString(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				return NewAddrResult(i.ethereum, addr, ""), nil
+			},
+		},
+		{
+			Name:  "gettx",
+			Args:  []ethconsole.Arg{{Name: "HASH"}},
+			Group: "DB",
+			Help:  "Prints the transaction stored under the hash",
+			Run: func(args []string) (interface{}, error) {
+				hash, err := hex.DecodeString(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				data, _ := ethutil.Config.Db.Get(hash)
+				if len(data) == 0 {
+					return nil, errors.New("tx not found")
+				}
+
+				tx, err := DecodeTx(data)
+				if err != nil {
+					return nil, err
+				}
+
+				return NewTxResult(tx), nil
+			},
+		},
+		{
+			Name:  "proof",
+			Args:  []ethconsole.Arg{{Name: "KEY"}, {Name: "ROOT", Optional: true, Complete: i.completeBlocks}},
+			Group: "DB",
+			Help:  "Creates a Merkle proof of the hex encoded KEY, e.g. 646f67 for a key set with update dog. The key is looked up in the console's trie, or in the trie of ROOT, a root hash or a block.",
+			Run: func(args []string) (interface{}, error) {
+				key, err := hex.DecodeString(args[0])
+				if err != nil {
+					return nil, fmt.Errorf("invalid key '%s', keys are hex encoded", args[0])
+				}
+
+				db, root, err := i.trieRoot(optionalArg(args, 1))
+				if err != nil {
+					return nil, err
+				}
+
+				proof, err := NewProof(db, root, key)
+				if err != nil {
+					return nil, err
+				}
+
+				return NewProofResult(proof), nil
+			},
+		},
+		{
+			Name:  "verifyproof",
+			Args:  []ethconsole.Arg{{Name: "ROOT"}, {Name: "KEY"}, {Name: "PROOF"}},
+			Group: "DB",
+			Help:  "Verifies the Merkle proof of the hex encoded key against the root",
+			Run: func(args []string) (interface{}, error) {
+				var decoded [3][]byte
+				for n, arg := range args {
+					var err error
+					if decoded[n], err = hex.DecodeString(arg); err != nil {
+						return nil, err
+					}
+				}
+
+				proof, err := DecodeProof(decoded[0], decoded[1], decoded[2])
+				if err != nil {
+					return nil, err
+				}
+
+				if proof.Value, err = proof.Verify(); err != nil {
+					return nil, err
+				}
+
+				return NewProofResult(proof), nil
+			},
+		},
+		{
+			Name:  "dump",
+			Args:  []ethconsole.Arg{{Name: "ROOT", Optional: true, Complete: i.completeBlocks}},
+			Group: "DB",
+			Help:  "Lists the keys and values of the console's trie, or of ROOT, a root hash or a block",
+			Run: func(args []string) (interface{}, error) {
+				db, root, err := i.trieRoot(optionalArg(args, 0))
+				if err != nil {
+					return nil, err
+				}
+
+				entries, err := TrieEntries(db, root)
+				if err != nil {
+					return nil, err
+				}
+
+				return NewTrieDump(entries), nil
+			},
+		},
+		{
+			Name:  "diff",
+			Args:  []ethconsole.Arg{{Name: "FROM", Complete: i.completeBlocks}, {Name: "TO", Optional: true, Complete: i.completeBlocks}},
+			Group: "DB",
+			Help:  "Lists the keys added (+), removed (-) and changed (~) from the trie of FROM to the one of TO, root hashes or blocks. Given a block only, its state is compared to the one of its parent.",
+			Run: func(args []string) (interface{}, error) {
+				from, to := args[0], optionalArg(args, 1)
+				if len(to) == 0 {
+					block, err := i.findBlock(from)
+					if err != nil {
+						return nil, err
+					}
+					from, to = ethutil.Hex(block.PrevHash), ethutil.Hex(block.Hash())
+				}
+
+				fromDb, fromRoot, err := i.trieRoot(from)
+				if err != nil {
+					return nil, err
+				}
+
+				toDb, toRoot, err := i.trieRoot(to)
+				if err != nil {
+					return nil, err
+				}
+
+				changes, err := DiffTries(fromDb, fromRoot, toDb, toRoot)
+				if err != nil {
+					return nil, err
+				}
+
+				return NewTrieDiff(changes), nil
+			},
+		},
+		{
+			Name:  "dag",
+			Args:  []ethconsole.Arg{{Name: "HASH"}, {Name: "NONCE"}},
+			Group: "Dagger",
+			Help:  "Verifies a nonce with the given hash with dagger",
+			Run: func(args []string) (interface{}, error) {
+				return ethchain.DaggerVerify(ethutil.Big(args[0]), // hash
+					ethutil.BigPow(2, 36),     // diff
+					ethutil.Big(args[1])), nil // nonce
+			},
+		},
+		{
+			Name:  "decode",
+			Args:  []ethconsole.Arg{{Name: "STR"}},
+			Group: "Encoding",
+			Help:  "Decodes the RLP encoded string",
+			Run: func(args []string) (interface{}, error) {
+				value := hexValue(ethutil.NewValueFromBytes([]byte(args[0])).Val)
+
+				return ethconsole.Text(value, "%v", value), nil
+			},
+		},
+		{
+			Name:  "encode",
+			Args:  []ethconsole.Arg{{Name: "STR"}},
+			Group: "Encoding",
+			Help:  "RLP encodes the string",
+			Run: func(args []string) (interface{}, error) {
+				encoded := ethutil.Encode(args[0])
+
+				return ethconsole.Text(ethutil.Hex(encoded), "%q", encoded), nil
+			},
+		},
+		{
+			Name:  "accounts",
+			Group: "Accounts",
+			Help:  "Lists the accounts, the default account is marked with *",
+			Run: func(args []string) (interface{}, error) {
+				return NewAccountResults(i.keyRing), nil
+			},
+		},
+		{
+			Name:  "account",
+			Args:  []ethconsole.Arg{{Name: "ACCOUNT", Complete: i.completeAccounts}},
+			Group: "Accounts",
+			Help:  "Sets the default account used for signing",
+			Run: func(args []string) (interface{}, error) {
+				acc, err := i.keyRing.Find(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				if err := i.keyRing.SetDefault(acc); err != nil {
+					return nil, err
+				}
+
+				return ethconsole.Text(acc.Hex(), "using account '%s' %x", acc.Label, acc.Address), nil
+			},
+		},
+		{
+			Name:  "unlock",
+			Args:  []ethconsole.Arg{{Name: "ACCOUNT", Complete: i.completeAccounts}, {Name: "SECONDS", Optional: true}},
+			Group: "Accounts",
+			Help:  "Unlocks the account for signing, for one signature if SECONDS is omitted",
+			Run: func(args []string) (interface{}, error) {
+				var seconds int
+				if len(args) > 1 {
+					var err error
+					seconds, err = strconv.Atoi(args[1])
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				acc, err := UnlockFor(i.prompt, i.keyRing, args[0], seconds)
+				if err != nil {
+					return nil, err
+				}
+
+				if seconds == 0 {
+					return ethconsole.Text(acc.Hex(), "'%s' unlocked for one signature", acc.Label), nil
+				}
+
+				return ethconsole.Text(acc.Hex(), "'%s' unlocked for %ds", acc.Label, seconds), nil
+			},
+		},
+		{
+			Name:  "lock",
+			Args:  []ethconsole.Arg{{Name: "ACCOUNT", Complete: i.completeAccounts}},
+			Group: "Accounts",
+			Help:  "Locks the account again",
+			Run: func(args []string) (interface{}, error) {
+				acc, err := i.keyRing.Find(args[0])
+				if err != nil {
+					return nil, err
+				}
+				i.keyRing.Lock(acc)
+
+				return nil, nil
+			},
+		},
+		{
+			Name:  "resetnonce",
+			Args:  []ethconsole.Arg{{Name: "ACCOUNT", Optional: true, Complete: i.completeAccounts}},
+			Group: "Accounts",
+			Help:  "Takes the next nonce of the account, the default one without ACCOUNT, from its state again. Use it when a queued transaction was dropped and the ones after it are stuck.",
+			Run: func(args []string) (interface{}, error) {
+				acc := i.keyRing.Default()
+				if len(args) > 0 {
+					var err error
+					if acc, err = i.keyRing.Find(args[0]); err != nil {
+						return nil, err
+					}
+				} else if acc == nil {
+					return nil, ethkeys.ErrNoKey
+				}
+				Nonces.Reset(acc.Address)
+
+				nonce := i.ethereum.BlockManager.GetAddrState(acc.Address).Nonce
+				return ethconsole.Text(nonce, "next nonce of '%s' is %d", acc.Label, nonce), nil
+			},
+		},
+		{
+			Name:  "newaccount",
+			Args:  []ethconsole.Arg{{Name: "LABEL"}},
+			Group: "Accounts",
+			Help:  "Creates a new account",
+			Run: func(args []string) (interface{}, error) {
+				acc, err := CreateAccount(i.prompt, i.keyRing, args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				return acc.Hex(), nil
+			},
+		},
+		{
+			Name:  "rmaccount",
+			Args:  []ethconsole.Arg{{Name: "ACCOUNT", Complete: i.completeAccounts}},
+			Group: "Accounts",
+			Help:  "Removes the account from the key ring",
+			Run: func(args []string) (interface{}, error) {
+				return nil, RemoveAccount(i.prompt, i.keyRing, args[0])
+			},
+		},
+		{
+			Name:  "sweep",
+			Args:  []ethconsole.Arg{{Name: "ACCOUNT", Complete: i.completeAccounts}},
+			Group: "Accounts",
+			Help:  "Moves the balance of the account to the default account",
+			Run: func(args []string) (interface{}, error) {
+				acc, err := i.keyRing.Find(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				tx, err := Sweep(i.prompt, i.ethereum, acc, i.keyRing.Default().Address, i.keyRing.UnlockedKey)
+				if err != nil {
+					return nil, err
+				}
+
+				return i.sent(tx), nil
+			},
+		},
+		{
+			Name:  "watch",
+			Args:  []ethconsole.Arg{{Name: "ADDR", Optional: true}, {Name: "LABEL", Optional: true}},
+			Group: "Accounts",
+			Help:  "Lists or adds watch-only addresses",
+			Run: func(args []string) (interface{}, error) {
+				if len(args) == 0 {
+					return NewWatchResults(i.ethereum, i.watchList), nil
+				}
+
+				var label string
+				if len(args) > 1 {
+					label = args[1]
+				}
+
+				return nil, ethui.WatchAddress(i.ethereum.BlockManager, i.keyRing, i.watchList, args[0], label)
+			},
+		},
+		{
+			Name:  "unwatch",
+			Args:  []ethconsole.Arg{{Name: "ADDR", Complete: i.completeWatched}},
+			Group: "Accounts",
+			Help:  "Removes the address from the watch list, its state is tracked until the node restarts",
+			Run: func(args []string) (interface{}, error) {
+				if err := ethui.UnwatchAddress(i.watchList, args[0]); err != nil {
+					return nil, err
+				}
+
+				return ethconsole.Text(args[0], "Removed %s from the watch list, its state is tracked until the node restarts", args[0]), nil
+			},
+		},
+		{
+			Name:  "addp",
+			Args:  []ethconsole.Arg{{Name: "HOST:PORT", Complete: i.completePeers}},
+			Group: "Network",
+			Help:  "Connects to the given peer",
+			Run: func(args []string) (interface{}, error) {
+				i.ethereum.ConnectToPeer(args[0])
+				i.knownPeers[args[0]] = true
+
+				return nil, nil
+			},
+		},
+		{
+			Name:  "pcount",
+			Group: "Network",
+			Help:  "Prints the number of connected peers",
+			Run: func(args []string) (interface{}, error) {
+				count := i.ethereum.Peers().Len()
+
+				return ethconsole.Text(count, "peers: %d", count), nil
+			},
+		},
+		{
+			Name:  "say",
+			Args:  []ethconsole.Arg{{Name: "MESSAGE"}},
+			Group: "Network",
+			Help:  "Broadcasts a talk message to the peers",
+			Run: func(args []string) (interface{}, error) {
+				i.ethereum.Broadcast(ethwire.MsgTalkTy, []interface{}{args[0]})
+
+				return nil, nil
+			},
+		},
+		{
+			Name:  "tx",
+			Args:  []ethconsole.Arg{{Name: "TO", Complete: i.completeAddrs}, {Name: "AMOUNT"}, {Name: "OPTIONS", Optional: true, Rest: true}},
+			Group: "Transactions",
+			Help:  "Sends AMOUNT from the default account to TO. AMOUNT is given in Wei unless a unit follows it, e.g. 2.5ether or 10 finney (wei, szabo, finney or ether). OPTIONS are nonce=N to override the nonce, dry to print the signed transaction without sending it, yes to skip the confirmation and data followed by the data of the transaction.",
+			Run: func(args []string) (interface{}, error) {
+				recipient, err := hex.DecodeString(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				arg, rest := SplitAmount(args[1], optionalArg(args, 2))
+				amount, err := ParseAmount(arg)
+				if err != nil {
+					return nil, err
+				}
+
+				opts, err := ParseTxOptions(rest)
+				if err != nil {
+					return nil, err
+				}
+
+				result, err := i.sendTx(recipient, amount, opts)
+				if err != nil {
+					return nil, err
+				}
+
+				return result, nil
+			},
+		},
+		{
+			Name:  "compile",
+			Args:  []ethconsole.Arg{{Name: "FILE"}},
+			Group: "Transactions",
+			Help:  "Compiles the contract source without creating the contract and prints the code along with a listing",
+			Run: func(args []string) (interface{}, error) {
+				compiled, err := CompileFile(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				return compiled, nil
+			},
+		},
+		{
+			Name:  "contract",
+			Args:  []ethconsole.Arg{{Name: "FILE"}, {Name: "AMOUNT"}, {Name: "OPTIONS", Optional: true, Rest: true}},
+			Group: "Transactions",
+			Help:  "Creates a contract with the code of the source FILE, - opens an editor. The contract is endowed with AMOUNT, OPTIONS are the ones of tx. The contract address is reported once the transaction is mined.",
+			Run: func(args []string) (interface{}, error) {
+				var compiled *Compiled
+				var err error
+				if args[0] == "-" {
+					i.prompt.Println("Contract editor (Ctrl-D or . = done)")
+					compiled, err = CompileSource(i.Editor())
+				} else {
+					compiled, err = CompileFile(args[0])
+				}
+				if err != nil {
+					return nil, err
+				}
+
+				arg, rest := SplitAmount(args[1], optionalArg(args, 2))
+				amount, err := ParseAmount(arg)
+				if err != nil {
+					return nil, err
+				}
+
+				opts, err := ParseTxOptions(rest)
+				if err != nil {
+					return nil, err
+				}
+
+				if len(opts.Data) > 1 || len(opts.Data[0]) > 0 {
+					return nil, errors.New("the data of contracts is their code")
+				}
+				opts.Data = compiled.Code
+
+				result, err := i.sendTx(ethchain.ContractAddr, amount, opts)
+				if err != nil {
+					return nil, err
+				}
+
+				if !result.DryRun {
+					go i.reportContract(i.lastTx, result.Address)
+				}
+
+				return result, nil
+			},
+		},
+		{
+			Name:  "disasm",
+			Args:  []ethconsole.Arg{{Name: "CODE", Complete: i.completeAddrs}, {Name: "FORMAT", Optional: true}},
+			Group: "Transactions",
+			Help:  fmt.Sprintf("Disassembles the code of the contract at an address, the data of the transaction with a hash or hex encoded RLP code. FORMAT source prints only the instructions, which compile back to the code. Contracts share their state with their storage, the code read from it ends after %d empty items and may include storage written next to the code, the transaction creating the contract has the exact code.", maxCodeGap),
+			Run: func(args []string) (interface{}, error) {
+				code, err := i.code(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				disasm := Disassemble(code)
+				switch optionalArg(args, 1) {
+				case "", "listing":
+					return disasm, nil
+				case "source":
+					return ethconsole.Text(disasm, "%s", strings.Join(disasm.Source, "\n")), nil
+				}
+
+				return nil, fmt.Errorf("unknown format '%s' (listing or source)", args[1])
+			},
+		},
+		{
+			Name:  "sendtx",
+			Args:  []ethconsole.Arg{{Name: "FILE"}},
+			Group: "Transactions",
+			Help:  "Validates and broadcasts a transaction signed offline",
+			Run: func(args []string) (interface{}, error) {
+				tx, err := SendTxFile(i.ethereum, args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				return i.sent(tx), nil
+			},
+		},
+		{
+			Name:  "signmsg",
+			Args:  []ethconsole.Arg{{Name: "MESSAGE", Rest: true}},
+			Group: "Transactions",
+			Help:  "Signs the rest of the line with the default account",
+			Run: func(args []string) (interface{}, error) {
+				key, err := i.GetKey()
+				if err != nil {
+					return nil, err
+				}
+
+				sig, err := ethkeys.SignMessage(key, []byte(args[0]))
+				if err != nil {
+					return nil, err
+				}
+
+				return ethutil.Hex(sig), nil
+			},
+		},
+		{
+			Name:  "verifymsg",
+			Args:  []ethconsole.Arg{{Name: "ADDR", Complete: i.completeAddrs}, {Name: "SIG"}, {Name: "MESSAGE", Rest: true}},
+			Group: "Transactions",
+			Help:  "Verifies ADDR signed the rest of the line",
+			Run: func(args []string) (interface{}, error) {
+				signer, err := VerifyMessage(args[0], args[1], args[2])
+				if err != nil {
+					return nil, err
+				}
+
+				return ethconsole.Text(ethutil.Hex(signer), "signer: %x\nsignature is valid", signer), nil
+			},
+		},
+		{
+			Name:    "exit",
+			Aliases: []string{"quit", "q"},
+			Help:    "Leaves the console",
+			Run: func(args []string) (interface{}, error) {
+				return nil, ethconsole.ErrQuit
+			},
+		},
+	}
 
-	count := 0
-	var tokens []string
-	for scanner.Scan() {
-		count++
-		tokens = append(tokens, scanner.Text())
+	i.register(commands)
+}
+
+// Returns the database and root of the trie named by the argument: the
+// console's trie if it's empty, otherwise the state of the block given by
+// hash or number or the trie with the root hash
+func (i *Console) trieRoot(arg string) (ethutil.Database, interface{}, error) {
+	i.trie.Sync()
+	if len(arg) == 0 {
+		return i.db, i.trie.Root, nil
 	}
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintln(os.Stderr, "reading input:", err)
+
+	if block, err := i.findBlock(arg); err == nil {
+		return ethutil.Config.Db, block.State().Root, nil
 	}
 
-	if len(tokens) == 0 {
-		return true
+	hash, err := hex.DecodeString(arg)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	err := i.ValidateInput(tokens[0], count-1)
+	for _, db := range []ethutil.Database{i.db, ethutil.Config.Db} {
+		if _, err := getNode(db, hash); err == nil {
+			return db, hash, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("no block or trie with root %x", hash)
+}
+
+// Returns the code named by the argument, the code of a contract given by
+// address, the data of a transaction given by hash or hex encoded RLP code
+func (i *Console) code(arg string) ([]string, error) {
+	data, err := hex.DecodeString(arg)
 	if err != nil {
-		fmt.Println(err)
-	} else {
-		switch tokens[0] {
-		case "update":
-			i.trie.Update(tokens[1], tokens[2])
-
-			i.PrintRoot()
-		case "get":
-			fmt.Println(i.trie.Get(tokens[1]))
-		case "root":
-			i.PrintRoot()
-		case "rawroot":
-			fmt.Println(i.trie.Root)
-		case "print":
-			i.db.Print()
-		case "dag":
-			fmt.Println(ethchain.DaggerVerify(ethutil.Big(tokens[1]), // hash
-				ethutil.BigPow(2, 36),   // diff
-				ethutil.Big(tokens[2]))) // nonce
-		case "decode":
-			value := ethutil.NewValueFromBytes([]byte(tokens[1]))
-			fmt.Println(value)
-		case "getaddr":
-			encoded, _ := hex.DecodeString(tokens[1])
-			addr := i.ethereum.BlockManager.BlockChain().CurrentBlock.GetAddr(encoded)
-			fmt.Println("addr:", addr)
-		case "block":
-			encoded, _ := hex.DecodeString(tokens[1])
-			block := i.ethereum.BlockManager.BlockChain().GetBlock(encoded)
-			info := block.BlockInfo()
-			fmt.Printf("++++++++++ #%d ++++++++++\n%v\n", info.Number, block)
-		case "say":
-			i.ethereum.Broadcast(ethwire.MsgTalkTy, []interface{}{tokens[1]})
-		case "addp":
-			i.ethereum.ConnectToPeer(tokens[1])
-		case "pcount":
-			fmt.Println("peers:", i.ethereum.Peers().Len())
-		case "encode":
-			fmt.Printf("%q\n", ethutil.Encode(tokens[1]))
-		case "tx":
-			recipient, err := hex.DecodeString(tokens[1])
+		return nil, err
+	}
+
+	switch len(data) {
+	case 20:
+		return ContractCode(i.ethereum, data)
+	case 32:
+		if raw, _ := ethutil.Config.Db.Get(data); len(raw) > 0 {
+			tx, err := DecodeTx(raw)
 			if err != nil {
-				fmt.Println("recipient err:", err)
-			} else {
-				tx := ethchain.NewTransaction(recipient, ethutil.Big(tokens[2]), []string{""})
+				return nil, err
+			}
 
-				key := ethutil.Config.Db.GetKeys()[0]
-				tx.Sign(key.PrivateKey)
-				i.ethereum.TxPool.QueueTransaction(tx)
+			return tx.Data, nil
+		}
+	}
 
-				fmt.Printf("%x\n", tx.Hash())
-			}
-		case "gettx":
-			addr, _ := hex.DecodeString(tokens[1])
-			data, _ := ethutil.Config.Db.Get(addr)
-			if len(data) != 0 {
-				decoder := ethutil.NewValueFromBytes(data)
-				fmt.Println(decoder)
-			} else {
-				fmt.Println("gettx: tx not found")
-			}
-		case "contract":
-			fmt.Println("Contract editor (Ctrl-D = done)")
-			code := ethchain.Compile(i.Editor())
-
-			contract := ethchain.NewTransaction(ethchain.ContractAddr, ethutil.Big(tokens[1]), code)
-
-			key := ethutil.Config.Db.GetKeys()[0]
-			contract.Sign(key.PrivateKey)
-
-			i.ethereum.TxPool.QueueTransaction(contract)
-
-			fmt.Printf("%x\n", contract.Hash()[12:])
-		case "exit", "quit", "q":
-			return false
-		case "help":
-			fmt.Printf("COMMANDS:\n" +
-				"\033[1m= DB =\033[0m\n" +
-				"update KEY VALUE - Updates/Creates a new value for the given key\n" +
-				"get KEY - Retrieves the given key\n" +
-				"root - Prints the hex encoded merkle root\n" +
-				"rawroot - Prints the raw merkle root\n" +
-				"block HASH - Prints the block\n" +
-				"getaddr ADDR - Prints the account associated with the address\n" +
-				"\033[1m= Dagger =\033[0m\n" +
-				"dag HASH NONCE - Verifies a nonce with the given hash with dagger\n" +
-				"\033[1m= Encoding =\033[0m\n" +
-				"decode STR\n" +
-				"encode STR\n" +
-				"\033[1m= Other =\033[0m\n" +
-				"addp HOST:PORT\n" +
-				"tx TO AMOUNT\n" +
-				"contract AMOUNT\n")
-
-		default:
-			fmt.Println("Unknown command:", tokens[0])
+	return DecodeCode(arg)
+}
+
+// Remembers the transaction sent from the console and returns it as result,
+// shown as its hash in text mode
+func (i *Console) sent(tx *ethchain.Transaction) interface{} {
+	i.lastTx = tx.Hash()
+
+	return ethconsole.Text(NewTxResult(tx), "%x", tx.Hash())
+}
+
+func (i *Console) register(commands []*ethconsole.Command) {
+	for _, cmd := range commands {
+		if err := i.commands.Register(cmd); err != nil {
+			i.prompt.Println("console err:", err)
+		}
+	}
+}
+
+// Returns the registry of the console's commands. Commands registered with it
+// are available right away.
+func (i *Console) Commands() *ethconsole.Registry {
+	return i.commands
+}
+
+// Runs the command on the line and prints its result. Returns false when the
+// console should stop.
+func (i *Console) ParseInput(input string) bool {
+	return i.commands.Run(input) != ethconsole.ErrQuit
+}
+
+// Number of recent blocks offered when completing block hashes and searched
+// for transactions
+const recentBlockCount = 64
+
+// Returns the most recent blocks, newest first
+func (i *Console) recentBlocks() []*ethchain.Block {
+	var blocks []*ethchain.Block
+
+	chain := i.ethereum.BlockManager.BlockChain()
+	block := chain.CurrentBlock
+	for n := 0; block != nil && n < recentBlockCount; n++ {
+		blocks = append(blocks, block)
+		if !chain.HasBlock(block.PrevHash) {
+			break
+		}
+		block = chain.GetBlock(block.PrevHash)
+	}
+
+	return blocks
+}
+
+func (i *Console) completeAccounts(prefix string) []string {
+	var labels []string
+	for _, acc := range i.keyRing.Accounts() {
+		labels = append(labels, acc.Label)
+	}
+
+	return ethconsole.Filter(prefix, labels)
+}
+
+func (i *Console) completeWatched(prefix string) []string {
+	var addrs []string
+	for _, w := range i.watchList.Entries() {
+		addrs = append(addrs, w.Hex())
+	}
+
+	return ethconsole.Filter(prefix, addrs)
+}
+
+// Completes the addresses of the accounts and the watched addresses
+func (i *Console) completeAddrs(prefix string) []string {
+	addrs := i.completeWatched(prefix)
+	for _, acc := range i.keyRing.Accounts() {
+		addrs = append(addrs, acc.Hex())
+	}
+
+	return ethconsole.Filter(prefix, addrs)
+}
+
+// Completes the connected peers and the peers added before
+func (i *Console) completePeers(prefix string) []string {
+	var peers []string
+	for addr := range i.knownPeers {
+		peers = append(peers, addr)
+	}
+
+	for _, addr := range PeerAddrs(i.ethereum) {
+		if !i.knownPeers[addr] {
+			peers = append(peers, addr)
 		}
 	}
 
-	return true
+	return ethconsole.Filter(prefix, peers)
+}
+
+// Returns the host:port addresses of the connected peers
+func PeerAddrs(ethereum *eth.Ethereum) []string {
+	var addrs []string
+	for e := ethereum.Peers().Front(); e != nil; e = e.Next() {
+		peer := e.Value.(*eth.Peer)
+		addrs = append(addrs, fmt.Sprintf("%v:%d", net.IP(peer.Host()), peer.Port()))
+	}
+
+	return addrs
+}
+
+// Completes the hashes of the most recent blocks
+func (i *Console) completeBlocks(prefix string) []string {
+	var hashes []string
+	for _, block := range i.recentBlocks() {
+		hashes = append(hashes, ethutil.Hex(block.Hash()))
+	}
+
+	return ethconsole.Filter(prefix, hashes)
+}
+
+// Console histories are kept in the data dir under the given name
+func historyPath(name string) string {
+	return path.Join(ethutil.Config.ExecPath, name)
+}
+
+// Loads the history of earlier sessions into the line editor and returns it
+func readHistory(line *liner.State, name string) []byte {
+	data, err := ioutil.ReadFile(historyPath(name))
+	if err != nil {
+		return nil
+	}
+	line.ReadHistory(bytes.NewReader(data))
+
+	return data
+}
+
+func writeHistory(line *liner.State, name string) {
+	f, err := os.OpenFile(historyPath(name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Println("history err:", err)
+		return
+	}
+	defer f.Close()
+
+	line.WriteHistory(f)
 }
 
 func (i *Console) Start() {
 	fmt.Printf("Eth Console. Type (help) for help\n")
-	reader := bufio.NewReader(os.Stdin)
+	go ReportWatchedTxs(i.ethereum, i.watchList)
+
+	line := liner.NewLiner()
+	defer line.Close()
+
+	// Liner buffers stdin, confirmations and passphrases have to read
+	// through it
+	i.prompt.SetLineReader(line.Prompt)
+	i.prompt.SetPasswordReader(line.PasswordPrompt)
+	defer i.prompt.SetLineReader(nil)
+	defer i.prompt.SetPasswordReader(nil)
+
+	line.SetCompleter(i.commands.Complete)
+
+	// Peers added in earlier sessions are offered for completion again
+	history := readHistory(line, "console_history")
+	for _, l := range strings.Split(string(history), "\n") {
+		if fields := strings.Fields(l); len(fields) == 2 && fields[0] == "addp" {
+			i.knownPeers[fields[1]] = true
+		}
+	}
+
+	var last string
 	for {
-		fmt.Printf("eth >>> ")
-		str, _, err := reader.ReadLine()
-		if err != nil {
+		str, err := line.Prompt("eth >>> ")
+		if err == liner.ErrPromptAborted {
+			continue
+		} else if err == io.EOF {
+			fmt.Println()
+			break
+		} else if err != nil {
 			fmt.Println("Error reading input", err)
-		} else {
-			if !i.ParseInput(string(str)) {
-				return
-			}
+			break
+		}
+
+		// The history is saved right away, the node may be stopped before the
+		// console is left
+		if str = strings.TrimSpace(str); len(str) > 0 && str != last {
+			line.AppendHistory(str)
+			writeHistory(line, "console_history")
+			last = str
+		}
+
+		if !i.ParseInput(str) {
+			break
 		}
 	}
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethutil"
+	"math/big"
+	"strings"
+)
+
+// Contract code is a list of instructions, opcodes and the numbers they work
+// on. The item following PUSH is always its argument, any other item of one
+// byte is read as an opcode and anything else as a number.
+type DisasmInstruction struct {
+	Offset int    `json:"offset"`
+	Code   string `json:"code"`
+	// The mnemonic, or the decimal number for arguments and data
+	Op string `json:"op"`
+	// Set for the arguments of PUSH
+	Arg bool `json:"arg,omitempty"`
+	// Set for items Compile can't produce, numbers with leading zero bytes
+	Raw bool `json:"raw,omitempty"`
+}
+
+type Disassembly struct {
+	Instructions []*DisasmInstruction `json:"instructions"`
+	// Compiles back to the same code, one instruction per line
+	Source []string `json:"source"`
+}
+
+// Disassembled code with empty items isn't necessarily the end of the code,
+// items are only stored in the contract's state when they aren't empty
+const maxCodeGap = 16
+
+// Turns the code back into instructions. Compiling the source of the
+// disassembly with ethchain.Compile gives the code again unless there are raw
+// items.
+func Disassemble(code []string) *Disassembly {
+	disasm := &Disassembly{}
+
+	mnemonics := make(map[string]string)
+	for name, op := range ethchain.OpCodes {
+		mnemonics[string([]byte{op})] = name
+	}
+
+	var push bool
+	for offset, item := range code {
+		instr := &DisasmInstruction{Offset: offset, Code: ethutil.Hex([]byte(item)), Arg: push}
+
+		if name, ok := mnemonics[item]; ok && !push {
+			instr.Op = name
+		} else {
+			instr.Op = new(big.Int).SetBytes([]byte(item)).String()
+			// Compile drops leading zeros, only the code shows the item
+			instr.Raw = len(item) > 0 && item[0] == 0
+		}
+		push = !push && instr.Op == "PUSH"
+
+		disasm.Instructions = append(disasm.Instructions, instr)
+		disasm.Source = append(disasm.Source, instr.Op)
+	}
+
+	return disasm
+}
+
+func (d *Disassembly) String() string {
+	var lines []string
+	for _, instr := range d.Instructions {
+		op := instr.Op
+		if instr.Arg {
+			op = "  " + op
+		}
+		if instr.Raw {
+			op += " (raw " + instr.Code + ")"
+		}
+
+		lines = append(lines, fmt.Sprintf("%04d %-10s %s", instr.Offset, instr.Code, op))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Decodes the hex encoded RLP list of code items, the data of a transaction
+func DecodeCode(hexCode string) ([]string, error) {
+	data, err := hex.DecodeString(hexCode)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := ethutil.NewValueFromBytes(data)
+	if !isList(decoder) {
+		return nil, errors.New("code isn't an RLP list")
+	}
+
+	code := make([]string, decoder.Len())
+	for i := range code {
+		code[i] = decoder.Get(i).Str()
+	}
+
+	return code, nil
+}
+
+// Reads the code of the contract from its state in the current block. The
+// code is stored item by item under the item's offset, storage shares the
+// state with it. The code is taken to end after maxCodeGap empty items, the
+// result is a best guess which may include storage or miss code following a
+// longer gap.
+func ContractCode(ethereum *eth.Ethereum, addr []byte) ([]string, error) {
+	contract := ethereum.BlockManager.BlockChain().CurrentBlock.GetContract(addr)
+	if contract == nil {
+		return nil, fmt.Errorf("no contract at %x", addr)
+	}
+
+	entries, err := TrieEntries(ethutil.Config.Db, contract.State().Root)
+	if err != nil {
+		return nil, err
+	}
+
+	var code []string
+	for _, e := range entries {
+		offset := new(big.Int).SetBytes(e.Key)
+		if offset.Cmp(big.NewInt(int64(len(code)+maxCodeGap))) > 0 {
+			break
+		}
+
+		for int64(len(code)) < offset.Int64() {
+			code = append(code, "")
+		}
+		code = append(code, ethutil.NewValueFromBytes(e.Value).Str())
+	}
+
+	return code, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ethereum/eth-go/ethchain"
+	"testing"
+)
+
+func TestDisassembleRoundTrip(t *testing.T) {
+	// Arguments of PUSH and data items which read as opcodes
+	add := fmt.Sprint(ethchain.OpCodes["ADD"])
+	push := fmt.Sprint(ethchain.OpCodes["PUSH"])
+	source := []string{"PUSH", add, "PUSH", push, add, "ADD", "1000", "0", "STOP"}
+	code := ethchain.Compile(source)
+	// Compile can't produce items with leading zero bytes
+	code = append(code, "\x00\x05")
+
+	disasm := Disassemble(code)
+	if len(disasm.Instructions) != len(code) {
+		t.Fatalf("expected %d instructions, got %d", len(code), len(disasm.Instructions))
+	}
+
+	for _, n := range []int{1, 3} {
+		instr := disasm.Instructions[n]
+		if !instr.Arg || instr.Op != source[n] {
+			t.Errorf("%d: expected the PUSH argument %s, got %s (arg %v)", n, source[n], instr.Op, instr.Arg)
+		}
+	}
+
+	if op := disasm.Instructions[4].Op; op != "ADD" {
+		t.Errorf("4: expected ADD, got %s", op)
+	}
+
+	for n, instr := range disasm.Instructions {
+		if instr.Raw != (n == len(code)-1) {
+			t.Errorf("%d: unexpected raw %v", n, instr.Raw)
+		}
+	}
+
+	recompiled := ethchain.Compile(disasm.Source)
+	for n := range code[:len(code)-1] {
+		if recompiled[n] != code[n] {
+			t.Errorf("%d: expected %x, got %x", n, code[n], recompiled[n])
+		}
+	}
+}
+
+func TestDecodeCode(t *testing.T) {
+	compiled, err := CompileSource([]string{"PUSH", "10", "0x00ff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := DecodeCode(compiled.Hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(code) != len(compiled.Code) {
+		t.Fatalf("expected %d items, got %d", len(compiled.Code), len(code))
+	}
+
+	for n := range code {
+		if code[n] != compiled.Code[n] {
+			t.Errorf("%d: expected %x, got %x", n, compiled.Code[n], code[n])
+		}
+	}
+
+	if _, err := DecodeCode("zz"); err == nil {
+		t.Error("expected an error for invalid hex")
+	}
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/ethereum/eth-go"
 	"github.com/ethereum/eth-go/ethchain"
 	"github.com/ethereum/eth-go/ethutil"
-	"github.com/ethereum/eth-go/ethwire"
+	"github.com/ethereum/go-ethereum/keys"
 	"github.com/ethereum/go-ethereum/ui"
 	"github.com/niemeyer/qml"
 	"github.com/obscuren/secp256k1-go"
@@ -17,30 +19,39 @@ import (
 
 const Debug = true
 
-// Register interrupt handlers so we can stop the ethereum
-func RegisterInterupts(s *eth.Ethereum) {
+// Calls the callback on every interrupt
+func HandleInterrupts(cb func(os.Signal)) {
 	// Buffered chan of one is enough
 	c := make(chan os.Signal, 1)
 	// Notify about interrupts for now
 	signal.Notify(c, os.Interrupt)
 	go func() {
 		for sig := range c {
-			fmt.Printf("Shutting down (%v) ... \n", sig)
-
-			s.Stop()
+			cb(sig)
 		}
 	}()
 }
 
-func CreateKeyPair(force bool) {
-	data, _ := ethutil.Config.Db.Get([]byte("KeyRing"))
-	if len(data) == 0 || force {
+// Register interrupt handlers so we can stop the ethereum
+func RegisterInterupts(s *eth.Ethereum) {
+	HandleInterrupts(func(sig os.Signal) {
+		fmt.Printf("Shutting down (%v) ... \n", sig)
+
+		s.Stop()
+	})
+}
+
+func CreateKeyPair(keyRing *ethkeys.KeyRing, force bool) error {
+	if keyRing.Len() == 0 || force {
 		pub, prv := secp256k1.GenerateKeyPair()
 		pair := &ethutil.Key{PrivateKey: prv, PublicKey: pub}
-		ethutil.Config.Db.Put([]byte("KeyRing"), pair.RlpEncode())
+
+		fmt.Println("Generating new address and keypair. Choose a passphrase to encrypt it with.")
+		if err := StoreKey(keyRing, pair); err != nil {
+			return err
+		}
 
 		fmt.Printf(`
-Generating new address and keypair.
 Please keep your keys somewhere save.
 
 ++++++++++++++++ KeyRing +++++++++++++++++++
@@ -52,27 +63,75 @@ pubk: %x
 `, pair.Address(), prv, pub)
 
 	}
+
+	return nil
 }
 
-func ImportPrivateKey(prvKey string) {
-	key := ethutil.FromHex(prvKey)
-	msg := []byte("tmp")
-	// Couldn't think of a better way to get the pub key
-	sig, _ := secp256k1.Sign(msg, key)
-	pub, _ := secp256k1.RecoverPubkey(msg, sig)
-	pair := &ethutil.Key{PrivateKey: key, PublicKey: pub}
-	ethutil.Config.Db.Put([]byte("KeyRing"), pair.RlpEncode())
+func ImportPrivateKey(keyRing *ethkeys.KeyRing, pair *ethutil.Key) error {
+	fmt.Println("Importing private key. Choose a passphrase to encrypt it with.")
+	if err := StoreKey(keyRing, pair); err != nil {
+		return err
+	}
 
 	fmt.Printf(`
-Importing private key
-
 ++++++++++++++++ KeyRing +++++++++++++++++++
 addr: %x
 prvk: %x
 pubk: %x
 ++++++++++++++++++++++++++++++++++++++++++++
 
-`, pair.Address(), key, pub)
+`, pair.Address(), pair.PrivateKey, pair.PublicKey)
+
+	return nil
+}
+
+// Asks for a new passphrase and stores the key encrypted in the key ring. The
+// key replaces the default account, the previous default is archived.
+func StoreKey(keyRing *ethkeys.KeyRing, pair *ethutil.Key) error {
+	pass, err := ReadNewPassphrase()
+	if err != nil {
+		return err
+	}
+
+	label := ethkeys.DefaultLabel
+	if def := keyRing.Default(); def != nil {
+		label = def.Label
+	}
+
+	acc, err := ethkeys.NewAccount(label, pair, pass)
+	if err != nil {
+		return err
+	}
+
+	old, err := keyRing.Rotate(acc)
+	if err != nil {
+		return err
+	}
+
+	if old != nil {
+		fmt.Printf("Archived the previous key as '%s' %x\n", old.Label, old.Address)
+		if SweepOld {
+			QueueSweep(old, acc)
+		}
+	}
+
+	return nil
+}
+
+// Key rings created by older versions hold the private key in plain text.
+// Those are encrypted with a passphrase of the user's choosing on start up.
+func MigrateKeyRing(keyRing *ethkeys.KeyRing) error {
+	if !keyRing.IsPlain() {
+		return nil
+	}
+
+	fmt.Println("Your private key is stored unencrypted. Choose a passphrase to encrypt it with.")
+	pass, err := ReadNewPassphrase()
+	if err != nil {
+		return err
+	}
+
+	return keyRing.Migrate(pass)
 }
 
 func main() {
@@ -80,7 +139,7 @@ func main() {
 
 	// Qt has to be initialized in the main thread or it will throw errors
 	// It has to be called BEFORE setting the maximum procs.
-	if UseGui {
+	if UseGui && !AttachConsole {
 		qml.Init(nil)
 	}
 
@@ -88,6 +147,10 @@ func main() {
 
 	ethchain.InitFees()
 	ethutil.ReadConfig(DataDir)
+
+	if AttachConsole {
+		Exit(Attach())
+	}
 	ethutil.Config.Seed = UseSeed
 
 	// Instantiated a eth stack
@@ -98,49 +161,130 @@ func main() {
 	}
 	ethereum.Port = OutboundPort
 
+	Prompt.AssumeYes = AssumeYes
+	Prompt.NoPrompt = NoPrompt
+	if len(PassFile) > 0 {
+		if Prompt.Passphrase, err = ReadPassphraseFile(PassFile); err != nil {
+			Exit(err)
+		}
+	}
+
+	keyRing := ethkeys.NewKeyRing()
+	if err := MigrateKeyRing(keyRing); err != nil {
+		Exit(err)
+	}
+
+	if len(UseAccount) > 0 {
+		acc, err := keyRing.Find(UseAccount)
+		if err == nil {
+			err = keyRing.SetDefault(acc)
+		}
+
+		if err != nil {
+			Exit(err)
+		}
+	}
+
+	if ListAccounts {
+		PrintAccounts(Prompt, keyRing)
+		Exit(nil)
+	}
+
+	if len(AddAccount) > 0 {
+		acc, err := CreateAccount(Prompt, keyRing, AddAccount)
+		if err == nil {
+			fmt.Printf("created account '%s' %x\n", acc.Label, acc.Address)
+		}
+		Exit(err)
+	}
+
+	if len(DelAccount) > 0 {
+		Exit(RemoveAccount(Prompt, keyRing, DelAccount))
+	}
+
+	if len(ImportFile) > 0 {
+		acc, err := ImportKeyFile(keyRing, ImportFile)
+		if err == nil {
+			fmt.Printf("imported account '%s' %x\n", acc.Label, acc.Address)
+		}
+		Exit(err)
+	}
+
+	if CreateHD || RestoreHD {
+		if keyRing.IsHD() && !Confirm("This action replaces your wallet seed. Are you sure?") {
+			Exit(ErrAborted)
+		}
+
+		if CreateHD {
+			Exit(CreateHDWallet(keyRing))
+		}
+		Exit(RestoreHDWallet(keyRing, HDAccounts))
+	}
+
+	if len(VanityPrefix) > 0 || len(VanitySuffix) > 0 {
+		Exit(GenerateVanityKey(keyRing, VanityPrefix, VanitySuffix, VanityFile))
+	}
+
 	if GenAddr {
-		fmt.Println("This action overwrites your old private key. Are you sure? (y/n)")
-
-		var r string
-		fmt.Scanln(&r)
-		for ; ; fmt.Scanln(&r) {
-			if r == "n" || r == "y" {
-				break
-			} else {
-				fmt.Printf("Yes or no?", r)
-			}
+		if !Confirm("This action replaces your default account, the old key is archived. Continue?") {
+			Exit(ErrAborted)
 		}
+		Exit(CreateKeyPair(keyRing, true))
+	}
 
-		if r == "y" {
-			CreateKeyPair(true)
+	if len(ImportKey) > 0 {
+		key, err := ethkeys.NewKeyFromHex(ImportKey)
+		if err != nil {
+			Exit(err)
 		}
-		os.Exit(0)
-	} else {
-		if len(ImportKey) > 0 {
-			fmt.Println("This action overwrites your old private key. Are you sure? (y/n)")
-			var r string
-			fmt.Scanln(&r)
-			for ; ; fmt.Scanln(&r) {
-				if r == "n" || r == "y" {
-					break
-				} else {
-					fmt.Printf("Yes or no?", r)
-				}
-			}
 
-			if r == "y" {
-				ImportPrivateKey(ImportKey)
-				os.Exit(0)
-			}
-		} else {
-			CreateKeyPair(false)
+		fmt.Printf("Importing the private key of address %x\n", key.Address())
+		if !Confirm("This action replaces your default account, the old key is archived. Continue?") {
+			Exit(ErrAborted)
 		}
+		Exit(ImportPrivateKey(keyRing, key))
+	}
+
+	if err := CreateKeyPair(keyRing, false); err != nil {
+		Exit(err)
 	}
 
 	if ExportKey {
-		key := ethutil.Config.Db.GetKeys()[0]
-		fmt.Printf("%x\n", key.PrivateKey)
-		os.Exit(0)
+		key, err := UnlockAccount(keyRing.Default())
+		if err == nil {
+			fmt.Printf("%x\n", key.PrivateKey)
+		}
+		Exit(err)
+	}
+
+	if len(ExportFile) > 0 {
+		Exit(ExportKeyFile(keyRing.Default(), ExportFile))
+	}
+
+	if len(SignMsg) > 0 {
+		key, err := UnlockAccount(keyRing.Default())
+		if err == nil {
+			err = SignMessage(Prompt, key, SignMsg)
+		}
+		Exit(err)
+	}
+
+	if len(VerifyMsg) > 0 {
+		signer, err := VerifyMessage(MsgSigner, MsgSig, VerifyMsg)
+		if signer != nil {
+			fmt.Printf("signer: %x\n", signer)
+		}
+		if err == nil && len(MsgSigner) > 0 {
+			fmt.Println("signature is valid")
+		}
+		Exit(err)
+	}
+
+	if len(SignTx) > 0 {
+		if TxNonce < 0 {
+			Exit(errors.New("offline transactions require an explicit -txnonce"))
+		}
+		Exit(SignTxFile(keyRing.Default(), TxTo, TxValue, uint64(TxNonce), TxData, SignTx, TxFormat))
 	}
 
 	if ShowGenesis {
@@ -153,19 +297,72 @@ func main() {
 	// Set the max peers
 	ethereum.MaxPeers = MaxPeer
 
-	if StartConsole {
-		err := os.Mkdir(ethutil.Config.ExecPath, os.ModePerm)
-		// Error is OK if the error is ErrExist
-		if err != nil && !os.IsExist(err) {
-			log.Panic("Unable to create EXECPATH:", err)
+	watchList := ethkeys.NewWatchList()
+	for _, w := range watchList.Entries() {
+		ethereum.BlockManager.WatchAddr(w.Address)
+	}
+
+	// Passphrases for queued sweeps are asked for before the consoles take
+	// over the terminal
+	sweeps := UnlockSweeps(Prompt, keyRing)
+
+	if len(SendTx) > 0 {
+		tx, err := SendTxFile(ethereum, SendTx)
+		if err != nil {
+			Exit(err)
+		}
+		log.Printf("Queued tx %x from %s\n", tx.Hash(), SendTx)
+	}
+
+	coinbase := keyRing.Default()
+	if len(Coinbase) > 0 {
+		coinbase, err = keyRing.Find(Coinbase)
+		if err != nil {
+			log.Println("coinbase err:", err)
+			os.Exit(1)
 		}
+	}
+	miner := NewMiner(ethereum, coinbase.Address)
+
+	// Scripts run headless, the console and GUI would compete for the node
+	if len(Script) > 0 {
+		StartConsole, StartJSConsole, UseGui = false, false, false
+	}
 
-		console := NewConsole(ethereum)
+	err = os.Mkdir(ethutil.Config.ExecPath, 0700)
+	// Error is OK if the error is ErrExist
+	if err != nil && !os.IsExist(err) {
+		log.Panic("Unable to create EXECPATH:", err)
+	}
+
+	// Lets -attach open consoles on the running node
+	var server *ConsoleServer
+	if len(Script) == 0 {
+		if server, err = StartConsoleServer(ethereum, keyRing, watchList); err != nil {
+			log.Println("console socket err:", err)
+		}
+	}
+
+	// Both consoles read stdin, the JavaScript console takes precedence
+	if StartJSConsole {
+		console := NewJSConsole(ethereum, keyRing, watchList, miner)
+		for _, path := range flag.Args() {
+			if err := console.Load(path); err != nil {
+				log.Println("js err:", err)
+			}
+		}
+		go console.Start()
+	} else if StartConsole {
+		console := NewConsole(ethereum, keyRing, watchList, Prompt)
 		go console.Start()
 	}
 
+	// Sweeps requested by -genaddr -sweep wait for the node to sync, the GUI
+	// only starts the node once it's told to connect
+	go SweepPending(ethereum, keyRing, sweeps)
+
 	if UseGui {
-		gui := ethui.New(ethereum)
+		gui := ethui.New(ethereum, keyRing, watchList)
 		gui.Start()
 		//ethereum.Stop()
 	} else {
@@ -173,38 +370,20 @@ func main() {
 		ethereum.Start()
 
 		if StartMining {
-			log.Printf("Miner started\n")
-
-			// Fake block mining. It broadcasts a new block every 5 seconds
-			go func() {
-				pow := &ethchain.EasyPow{}
-				data, _ := ethutil.Config.Db.Get([]byte("KeyRing"))
-				keyRing := ethutil.NewValueFromBytes(data)
-				addr := keyRing.Get(1).Bytes()
-
-				for {
-					txs := ethereum.TxPool.Flush()
-					// Create a new block which we're going to mine
-					block := ethereum.BlockManager.BlockChain().NewBlock(addr, txs)
-					// Apply all transactions to the block
-					ethereum.BlockManager.ApplyTransactions(block, block.Transactions())
-
-					ethereum.BlockManager.AccumelateRewards(block, block)
-
-					// Search the nonce
-					block.Nonce = pow.Search(block)
-					ethereum.Broadcast(ethwire.MsgBlockTy, []interface{}{block.Value().Val})
-					err := ethereum.BlockManager.ProcessBlock(block)
-					if err != nil {
-						log.Println(err)
-					} else {
-						log.Println("\n+++++++ MINED BLK +++++++\n", ethereum.BlockManager.BlockChain().CurrentBlock)
-					}
-				}
-			}()
+			miner.Start()
+		}
+
+		if len(Script) > 0 {
+			err := NewConsole(ethereum, keyRing, watchList, Prompt).RunScript(Script, KeepGoing)
+			ethereum.Stop()
+			Exit(err)
 		}
 
 		// Wait for shutdown
 		ethereum.WaitForShutdown()
 	}
+
+	if server != nil {
+		server.Stop()
+	}
 }
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/go-ethereum/console"
+	"strconv"
+)
+
+// Number of blocks listed by the blocks command unless told otherwise
+const defaultBlockCount = 10
+
+// Number of parent links followed at most to find a block by number or
+// generation, every link is a database read
+const maxBlockWalk = 10000
+
+var ErrBlockNotFound = errors.New("block not found")
+
+// Returns the block given by hash, by number or as "latest". Blocks are only
+// looked up once the chain is known to have them, BlockChain.GetBlock doesn't
+// cope with unknown hashes.
+func (i *Console) findBlock(arg string) (*ethchain.Block, error) {
+	chain := i.ethereum.BlockManager.BlockChain()
+	if chain.CurrentBlock == nil {
+		return nil, ErrBlockNotFound
+	}
+
+	if arg == "latest" {
+		return chain.CurrentBlock, nil
+	}
+
+	// Hashes are 32 bytes, anything shorter is a block number
+	if len(arg) == 64 {
+		hash, err := hex.DecodeString(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !chain.HasBlock(hash) {
+			return nil, ErrBlockNotFound
+		}
+
+		return chain.GetBlock(hash), nil
+	}
+
+	number, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is neither a block hash nor a number", arg)
+	}
+
+	current := chain.CurrentBlock.BlockInfo().Number
+	if number > current {
+		return nil, fmt.Errorf("block #%d not found, the chain ends at #%d", number, current)
+	}
+
+	if current-number > maxBlockWalk {
+		return nil, fmt.Errorf("block #%d is too far behind #%d, only the last %d blocks are found by number, use its hash", number, current, maxBlockWalk)
+	}
+
+	// Blocks are only indexed by hash, follow the parents back one at a time
+	// rather than loading the chain in between
+	block := chain.CurrentBlock
+	for block.BlockInfo().Number > number {
+		if block = i.parent(block); block == nil {
+			return nil, ErrBlockNotFound
+		}
+	}
+
+	if block.BlockInfo().Number != number {
+		return nil, ErrBlockNotFound
+	}
+
+	return block, nil
+}
+
+// Returns up to count blocks ending with the given one, oldest first
+func (i *Console) chain(block *ethchain.Block, count int) []*ethchain.Block {
+	var blocks []*ethchain.Block
+	for _, b := range i.ethereum.BlockManager.BlockChain().GetChain(block.Hash(), count) {
+		// The chain may end early when a parent is missing
+		if b != nil {
+			blocks = append(blocks, b)
+		}
+	}
+
+	return blocks
+}
+
+// Returns the block's parent, nil for the genesis block
+func (i *Console) parent(block *ethchain.Block) *ethchain.Block {
+	chain := i.ethereum.BlockManager.BlockChain()
+	if !chain.HasBlock(block.PrevHash) {
+		return nil
+	}
+
+	return chain.GetBlock(block.PrevHash)
+}
+
+func (i *Console) registerExplorerCommands() {
+	i.register([]*ethconsole.Command{
+		{
+			Name:  "block",
+			Args:  []ethconsole.Arg{{Name: "BLOCK", Complete: i.completeBlocks}},
+			Group: "Blocks",
+			Help:  "Prints the block given by hash, number or as latest",
+			Run: func(args []string) (interface{}, error) {
+				block, err := i.findBlock(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				return NewBlockResult(block), nil
+			},
+		},
+		{
+			Name:  "blocks",
+			Args:  []ethconsole.Arg{{Name: "COUNT", Optional: true}},
+			Group: "Blocks",
+			Help:  fmt.Sprintf("Lists the last COUNT blocks, %d by default", defaultBlockCount),
+			Run: func(args []string) (interface{}, error) {
+				count := defaultBlockCount
+				if len(args) > 0 {
+					var err error
+					if count, err = strconv.Atoi(args[0]); err != nil || count <= 0 {
+						return nil, fmt.Errorf("invalid count '%s'", args[0])
+					}
+				}
+
+				latest, err := i.findBlock("latest")
+				if err != nil {
+					return nil, err
+				}
+
+				list := BlockList{}
+				blocks := i.chain(latest, count)
+				// Newest first
+				for n := len(blocks) - 1; n >= 0; n-- {
+					list = append(list, NewBlockResult(blocks[n]))
+				}
+
+				return list, nil
+			},
+		},
+		{
+			Name:  "txs",
+			Args:  []ethconsole.Arg{{Name: "BLOCK", Complete: i.completeBlocks}},
+			Group: "Blocks",
+			Help:  "Lists the transactions of the block with their sender, recipient and value",
+			Run: func(args []string) (interface{}, error) {
+				block, err := i.findBlock(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				return TxList(NewBlockResult(block).Txs), nil
+			},
+		},
+		{
+			Name:  "parent",
+			Args:  []ethconsole.Arg{{Name: "BLOCK", Complete: i.completeBlocks}, {Name: "GENERATIONS", Optional: true}},
+			Group: "Blocks",
+			Help:  "Follows the parent links of the block, once unless GENERATIONS is given",
+			Run: func(args []string) (interface{}, error) {
+				generations := 1
+				if len(args) > 1 {
+					var err error
+					if generations, err = strconv.Atoi(args[1]); err != nil || generations <= 0 {
+						return nil, fmt.Errorf("invalid generations '%s'", args[1])
+					}
+				}
+
+				if generations > maxBlockWalk {
+					return nil, fmt.Errorf("generations above %d aren't followed, use block with a number", maxBlockWalk)
+				}
+
+				block, err := i.findBlock(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				// Blocks have as many ancestors as their number
+				if number := block.BlockInfo().Number; uint64(generations) > number {
+					return nil, fmt.Errorf("block #%d has only %d generations before it", number, number)
+				}
+
+				for n := 0; n < generations; n++ {
+					if block = i.parent(block); block == nil {
+						return nil, fmt.Errorf("the chain ends after %d generations", n)
+					}
+				}
+
+				return NewBlockResult(block), nil
+			},
+		},
+	})
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/eth-go"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
+	"github.com/peterh/liner"
+	"github.com/robertkrimen/otto"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"strings"
+)
+
+// The JavaScript console exposes the node as the global eth object:
+//
+//	eth.accounts()                        [ { label, address, default, unlocked }, ... ]
+//	eth.unlock(account, [seconds])        asks for the passphrase, one signature if seconds is omitted
+//	eth.lock(account)
+//	eth.createTx(account, to, value, [data]) hash of the queued tx, an empty to creates a contract.
+//	                                      Sent like the tx command with yes, the balance has to cover it.
+//	                                      The value is in Wei or has a unit like "2.5ether".
+//	eth.getBlock([hash])                  { number, hash, prevHash, txs }, the current block by default
+//	eth.balance(addr)                     balance in Wei as a string
+//	eth.peers()                           [ "host:port", ... ]
+//	eth.addPeer(addr)
+//	eth.startMining(), eth.stopMining(), eth.mining()
+//	eth.run(command)                      runs a developer console command and returns its result
+//
+// Accounts are given by label, address or index, an empty account is the
+// default account. Failures are thrown as errors. Scripts are loaded with
+// load(path).
+type JSConsole struct {
+	vm       *otto.Otto
+	ethereum *eth.Ethereum
+	keyRing  *ethkeys.KeyRing
+	miner    *Miner
+	// Runs eth.run commands
+	console *Console
+}
+
+func NewJSConsole(ethereum *eth.Ethereum, keyRing *ethkeys.KeyRing, watchList *ethkeys.WatchList, miner *Miner) *JSConsole {
+	js := &JSConsole{
+		vm:       otto.New(),
+		ethereum: ethereum,
+		keyRing:  keyRing,
+		miner:    miner,
+		console:  NewConsole(ethereum, keyRing, watchList, Prompt),
+	}
+	js.initVM()
+
+	return js
+}
+
+// Throws the error as a JavaScript Error
+func (js *JSConsole) throw(err error) {
+	value, _ := js.vm.Call("new Error", nil, err.Error())
+	panic(value)
+}
+
+func (js *JSConsole) value(v interface{}) otto.Value {
+	value, err := js.vm.ToValue(v)
+	if err != nil {
+		js.throw(err)
+	}
+
+	return value
+}
+
+func (js *JSConsole) account(call otto.FunctionCall, n int) *ethkeys.Account {
+	query := call.Argument(n)
+	if query.IsUndefined() || len(query.String()) == 0 {
+		acc := js.keyRing.Default()
+		if acc == nil {
+			js.throw(ethkeys.ErrNoKey)
+		}
+
+		return acc
+	}
+
+	acc, err := js.keyRing.Find(query.String())
+	if err != nil {
+		js.throw(err)
+	}
+
+	return acc
+}
+
+func (js *JSConsole) hexArg(call otto.FunctionCall, n int) []byte {
+	data, err := hex.DecodeString(call.Argument(n).String())
+	if err != nil {
+		js.throw(err)
+	}
+
+	return data
+}
+
+func (js *JSConsole) initVM() {
+	obj, _ := js.vm.Object("eth = {}")
+
+	obj.Set("accounts", func(call otto.FunctionCall) otto.Value {
+		def := js.keyRing.Default()
+
+		var accounts []interface{}
+		for _, acc := range js.keyRing.Accounts() {
+			accounts = append(accounts, map[string]interface{}{
+				"label":    acc.Label,
+				"address":  acc.Hex(),
+				"default":  acc == def,
+				"unlocked": js.keyRing.IsUnlocked(acc),
+			})
+		}
+
+		return js.value(accounts)
+	})
+
+	obj.Set("unlock", func(call otto.FunctionCall) otto.Value {
+		acc := js.account(call, 0)
+
+		var seconds int64
+		if !call.Argument(1).IsUndefined() {
+			var err error
+			if seconds, err = call.Argument(1).ToInteger(); err != nil {
+				js.throw(err)
+			}
+		}
+
+		if _, err := UnlockFor(Prompt, js.keyRing, acc.Hex(), int(seconds)); err != nil {
+			js.throw(err)
+		}
+
+		return otto.UndefinedValue()
+	})
+
+	obj.Set("lock", func(call otto.FunctionCall) otto.Value {
+		js.keyRing.Lock(js.account(call, 0))
+
+		return otto.UndefinedValue()
+	})
+
+	obj.Set("createTx", func(call otto.FunctionCall) otto.Value {
+		acc := js.account(call, 0)
+
+		to := ethchain.ContractAddr
+		if recipient := call.Argument(1); !recipient.IsUndefined() && len(recipient.String()) > 0 {
+			to = js.hexArg(call, 1)
+		}
+
+		code := []string{""}
+		if data := call.Argument(3); !data.IsUndefined() {
+			code = ethchain.Compile(strings.Split(data.String(), "\n"))
+		}
+
+		value := new(big.Int)
+		if amount := call.Argument(2); !amount.IsUndefined() {
+			var err error
+			if value, err = ParseAmount(amount.String()); err != nil {
+				js.throw(err)
+			}
+		}
+
+		// Scripts confirm by calling, the checks of the tx command still apply
+		result, err := js.console.sendTx(to, value, &TxOptions{From: acc, Data: code, Yes: true})
+		if err != nil {
+			js.throw(err)
+		}
+
+		return js.value(result.Hash)
+	})
+
+	obj.Set("getBlock", func(call otto.FunctionCall) otto.Value {
+		chain := js.ethereum.BlockManager.BlockChain()
+
+		block := chain.CurrentBlock
+		if !call.Argument(0).IsUndefined() {
+			hash := js.hexArg(call, 0)
+			if !chain.HasBlock(hash) {
+				return otto.NullValue()
+			}
+			block = chain.GetBlock(hash)
+		}
+
+		var txs []interface{}
+		for _, tx := range block.Transactions() {
+			txs = append(txs, ethutil.Hex(tx.Hash()))
+		}
+
+		return js.value(map[string]interface{}{
+			"number":   int(block.BlockInfo().Number),
+			"hash":     ethutil.Hex(block.Hash()),
+			"prevHash": ethutil.Hex(block.PrevHash),
+			"txs":      txs,
+		})
+	})
+
+	obj.Set("balance", func(call otto.FunctionCall) otto.Value {
+		addr := js.hexArg(call, 0)
+
+		return js.value(js.ethereum.BlockManager.GetAddrState(addr).Account.Amount.String())
+	})
+
+	obj.Set("peers", func(call otto.FunctionCall) otto.Value {
+		var peers []interface{}
+		for _, addr := range PeerAddrs(js.ethereum) {
+			peers = append(peers, addr)
+		}
+
+		return js.value(peers)
+	})
+
+	obj.Set("addPeer", func(call otto.FunctionCall) otto.Value {
+		js.ethereum.ConnectToPeer(call.Argument(0).String())
+
+		return otto.UndefinedValue()
+	})
+
+	obj.Set("startMining", func(call otto.FunctionCall) otto.Value {
+		js.miner.Start()
+
+		return otto.UndefinedValue()
+	})
+
+	obj.Set("stopMining", func(call otto.FunctionCall) otto.Value {
+		js.miner.Stop()
+
+		return otto.UndefinedValue()
+	})
+
+	obj.Set("mining", func(call otto.FunctionCall) otto.Value {
+		return js.value(js.miner.Mining())
+	})
+
+	obj.Set("run", func(call otto.FunctionCall) otto.Value {
+		result, err := js.console.commands.Exec(call.Argument(0).String())
+		if err != nil {
+			js.throw(err)
+		}
+
+		if result == nil {
+			return otto.UndefinedValue()
+		}
+
+		// Results are handed over the way the JSON output mode shows them
+		data, err := json.Marshal(result)
+		if err != nil {
+			js.throw(err)
+		}
+
+		value, err := js.vm.Call("JSON.parse", nil, string(data))
+		if err != nil {
+			js.throw(err)
+		}
+
+		return value
+	})
+
+	js.vm.Set("load", func(call otto.FunctionCall) otto.Value {
+		if err := js.Load(call.Argument(0).String()); err != nil {
+			js.throw(err)
+		}
+
+		return otto.UndefinedValue()
+	})
+}
+
+// Runs the JavaScript file
+func (js *JSConsole) Load(path string) error {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := js.vm.Run(string(src)); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	return nil
+}
+
+// Formats the result of an evaluation, objects are shown as JSON
+func (js *JSConsole) format(value otto.Value) string {
+	if value.IsObject() {
+		if json, err := js.vm.Call("JSON.stringify", nil, value); err == nil {
+			return json.String()
+		}
+	}
+
+	return value.String()
+}
+
+func (js *JSConsole) Start() {
+	fmt.Println("Eth JavaScript Console. The node is available as the eth object")
+
+	line := liner.NewLiner()
+	defer line.Close()
+	readHistory(line, "js_history")
+
+	// Liner buffers stdin, confirmations and passphrases have to read
+	// through it
+	Prompt.SetLineReader(line.Prompt)
+	Prompt.SetPasswordReader(line.PasswordPrompt)
+	defer Prompt.SetLineReader(nil)
+	defer Prompt.SetPasswordReader(nil)
+
+	var src string
+	for {
+		prompt := "js >>> "
+		if len(src) > 0 {
+			prompt = "... "
+		}
+
+		str, err := line.Prompt(prompt)
+		if err == liner.ErrPromptAborted {
+			src = ""
+			continue
+		} else if err == io.EOF {
+			fmt.Println()
+			break
+		} else if err != nil {
+			fmt.Println("Error reading input", err)
+			break
+		}
+
+		if len(strings.TrimSpace(str)) == 0 && len(src) == 0 {
+			continue
+		}
+		src += str + "\n"
+
+		value, err := js.vm.Run(src)
+		// Keep reading until unfinished statements and blocks are complete
+		if err != nil && strings.Contains(err.Error(), "Unexpected end of input") {
+			continue
+		}
+
+		line.AppendHistory(strings.TrimSpace(src))
+		writeHistory(line, "js_history")
+		src = ""
+
+		if err != nil {
+			fmt.Println(err)
+		} else if !value.IsUndefined() {
+			fmt.Println(js.format(value))
+		}
+	}
+}
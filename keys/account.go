@@ -0,0 +1,95 @@
+package ethkeys
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ethereum/eth-go/ethutil"
+)
+
+var (
+	ErrPlainKey     = errors.New("key isn't encrypted")
+	ErrAddrMismatch = errors.New("decrypted key doesn't match the account address")
+)
+
+// An account is a labeled key pair of which the private key is kept
+// encrypted. The address is stored in the clear so accounts can be listed
+// and watched without asking for the passphrase.
+type Account struct {
+	Label   string
+	Address []byte
+	// Archived accounts have been replaced by a newer key but can still sign
+	Archived bool
+
+	crypt *Crypt
+	// Only set for accounts loaded from an unencrypted key ring
+	plain *ethutil.Key
+}
+
+// Encrypts the key with the passphrase and wraps it in a new account
+func NewAccount(label string, key *ethutil.Key, passphrase string) (*Account, error) {
+	addr := key.Address()
+	crypt, err := Encrypt(key.RlpEncode(), passphrase, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Account{Label: label, Address: addr, crypt: crypt}, nil
+}
+
+func NewAccountFromValue(decoder *ethutil.Value) *Account {
+	acc := &Account{}
+	acc.RlpValueDecode(decoder)
+
+	return acc
+}
+
+func keyFromValue(decoder *ethutil.Value) *ethutil.Key {
+	return &ethutil.Key{PrivateKey: decoder.Get(0).Bytes(), PublicKey: decoder.Get(1).Bytes()}
+}
+
+// Decrypts and returns the account's key
+func (a *Account) Key(passphrase string) (*ethutil.Key, error) {
+	if a.plain != nil {
+		return nil, ErrPlainKey
+	}
+
+	data, err := a.crypt.Decrypt(passphrase, a.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keyFromValue(ethutil.NewValueFromBytes(data))
+	if bytes.Compare(key.Address(), a.Address) != 0 {
+		return nil, ErrAddrMismatch
+	}
+
+	return key, nil
+}
+
+// Returns whether the private key is still stored in plain text
+func (a *Account) IsPlain() bool {
+	return a.plain != nil
+}
+
+func (a *Account) Hex() string {
+	return ethutil.Hex(a.Address)
+}
+
+func (a *Account) RlpValue() []interface{} {
+	var archived uint64
+	if a.Archived {
+		archived = 1
+	}
+
+	return []interface{}{a.Label, a.Address, a.crypt.RlpValue(), archived}
+}
+
+func (a *Account) RlpValueDecode(decoder *ethutil.Value) {
+	a.Label = decoder.Get(0).Str()
+	a.Address = decoder.Get(1).Bytes()
+	a.crypt = &Crypt{}
+	a.crypt.RlpValueDecode(decoder.Get(2))
+	if decoder.Len() > 3 {
+		a.Archived = decoder.Get(3).Uint() != 0
+	}
+}
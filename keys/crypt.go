@@ -0,0 +1,102 @@
+package ethkeys
+
+import (
+	"code.google.com/p/go.crypto/scrypt"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"github.com/ethereum/eth-go/ethutil"
+)
+
+// Scrypt parameters used for newly encrypted secrets. N = 2^18 costs about a
+// second and 256MB of memory per attempt which makes brute forcing a weak
+// passphrase expensive. The parameters are stored next to the cipher text so
+// they can be raised later on without breaking existing key rings, tests
+// lower them.
+var (
+	ScryptN = 1 << 18
+	ScryptR = 8
+	ScryptP = 1
+)
+
+const (
+	keyLen  = 32
+	saltLen = 32
+)
+
+var ErrDecrypt = errors.New("unable to decrypt key (wrong passphrase?)")
+
+// Crypt holds everything but the passphrase that is required to decrypt a
+// secret. The secret is sealed with AES-256-GCM using a key derived from the
+// passphrase with scrypt.
+type Crypt struct {
+	N, R, P    int
+	Salt       []byte
+	Nonce      []byte
+	CipherText []byte
+}
+
+// Encrypts the secret with the given passphrase. The additional data isn't
+// encrypted but is authenticated, tampering with it makes decryption fail.
+func Encrypt(secret []byte, passphrase string, additional []byte) (*Crypt, error) {
+	c := &Crypt{N: ScryptN, R: ScryptR, P: ScryptP, Salt: make([]byte, saltLen)}
+	if _, err := rand.Read(c.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := c.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(c.Nonce); err != nil {
+		return nil, err
+	}
+	c.CipherText = aead.Seal(nil, c.Nonce, secret, additional)
+
+	return c, nil
+}
+
+// Decrypts the secret. The additional data must equal the data given to Encrypt
+func (c *Crypt) Decrypt(passphrase string, additional []byte) ([]byte, error) {
+	aead, err := c.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := aead.Open(nil, c.Nonce, c.CipherText, additional)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return secret, nil
+}
+
+func (c *Crypt) aead(passphrase string) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), c.Salt, c.N, c.R, c.P, keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (c *Crypt) RlpValue() []interface{} {
+	return []interface{}{uint64(c.N), uint64(c.R), uint64(c.P), c.Salt, c.Nonce, c.CipherText}
+}
+
+func (c *Crypt) RlpValueDecode(decoder *ethutil.Value) {
+	c.N = int(decoder.Get(0).Uint())
+	c.R = int(decoder.Get(1).Uint())
+	c.P = int(decoder.Get(2).Uint())
+	c.Salt = decoder.Get(3).Bytes()
+	c.Nonce = decoder.Get(4).Bytes()
+	c.CipherText = decoder.Get(5).Bytes()
+}
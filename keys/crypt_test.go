@@ -0,0 +1,36 @@
+package ethkeys
+
+import (
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	defer setupTest(t)()
+
+	secret := []byte("secret")
+	c, err := Encrypt(secret, testPass, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.N != ScryptN || c.R != ScryptR || c.P != ScryptP {
+		t.Errorf("expected the scrypt parameters to be stored, got %d %d %d", c.N, c.R, c.P)
+	}
+
+	decrypted, err := c.Decrypt(testPass, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(decrypted) != string(secret) {
+		t.Errorf("expected %q, got %q", secret, decrypted)
+	}
+
+	if _, err := c.Decrypt("wrong", []byte("data")); err != ErrDecrypt {
+		t.Error("expected ErrDecrypt for the wrong passphrase, got", err)
+	}
+
+	if _, err := c.Decrypt(testPass, []byte("other")); err != ErrDecrypt {
+		t.Error("expected ErrDecrypt for other additional data, got", err)
+	}
+}
@@ -0,0 +1,110 @@
+package ethkeys
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/tyler-smith/go-bip39"
+	"math/big"
+	"strings"
+)
+
+// Deterministic wallets derive all of their keys from a single seed which in
+// turn is generated from a BIP39 mnemonic phrase. Writing down the phrase is
+// enough to restore every account ever derived from it.
+//
+// Keys are derived with BIP32 hardened derivation only. Hardened derivation
+// doesn't need public key arithmetic, the drawback is that addresses can't be
+// derived without the seed, which isn't needed here. Account i is derived at
+//
+//	m/44'/60'/0'/i'
+const (
+	hardened      = 0x80000000
+	entropyLength = 128
+)
+
+var (
+	masterSecret   = []byte("Bitcoin seed")
+	derivationPath = []uint32{44 | hardened, 60 | hardened, 0 | hardened}
+
+	ErrInvalidChild = errors.New("derived key is invalid, use the next index")
+)
+
+// Generates a new random mnemonic phrase of twelve words
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(entropyLength)
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+// Validates the mnemonic phrase and returns the seed it represents
+func SeedFromMnemonic(mnemonic string) ([]byte, error) {
+	mnemonic = strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	if _, err := bip39.MnemonicToByteArray(mnemonic); err != nil {
+		return nil, err
+	}
+
+	return bip39.NewSeed(mnemonic, ""), nil
+}
+
+func hmacSha512(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+
+	return mac.Sum(nil)
+}
+
+// Pads the big int to 32 bytes so it's usable as a private key
+func bigToKey(i *big.Int) []byte {
+	key := make([]byte, 32)
+	b := i.Bytes()
+	copy(key[32-len(b):], b)
+
+	return key
+}
+
+// Derives the hardened child at index i from the parent key and chain code
+func childKey(key, chain []byte, i uint32) ([]byte, []byte, error) {
+	index := make([]byte, 4)
+	binary.BigEndian.PutUint32(index, i|hardened)
+
+	I := hmacSha512(chain, []byte{0}, key, index)
+	il := new(big.Int).SetBytes(I[:32])
+	if il.Cmp(curveOrder) >= 0 {
+		return nil, nil, ErrInvalidChild
+	}
+
+	k := il.Add(il, new(big.Int).SetBytes(key))
+	k.Mod(k, curveOrder)
+	if k.Sign() == 0 {
+		return nil, nil, ErrInvalidChild
+	}
+
+	return bigToKey(k), I[32:], nil
+}
+
+// Derives the key pair of the account at the given index from the seed
+func DeriveKey(seed []byte, index uint32) (*ethutil.Key, error) {
+	I := hmacSha512(masterSecret, seed)
+	key, chain := I[:32], I[32:]
+	if !validScalar(new(big.Int).SetBytes(key)) {
+		return nil, errors.New("seed results in an invalid master key")
+	}
+
+	var err error
+	for _, i := range append(derivationPath, index) {
+		key, chain, err = childKey(key, chain, i)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewKeyFromPrivate(key)
+}
@@ -0,0 +1,62 @@
+package ethkeys
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// BIP32 test vector 1, chain m/0H
+func TestChildKey(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	I := hmacSha512(masterSecret, seed)
+	if hex.EncodeToString(I[:32]) != "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35" {
+		t.Fatalf("unexpected master key %x", I[:32])
+	}
+
+	key, chain, err := childKey(I[:32], I[32:], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(key) != "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea" {
+		t.Errorf("unexpected key of m/0H %x", key)
+	}
+
+	if hex.EncodeToString(chain) != "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141" {
+		t.Errorf("unexpected chain code of m/0H %x", chain)
+	}
+}
+
+// The BIP39 test mnemonic, without passphrase, derived at m/44'/60'/0'/i'
+func TestDeriveKey(t *testing.T) {
+	seed, err := SeedFromMnemonic(" Abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(seed) != "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4" {
+		t.Fatalf("unexpected seed %x", seed)
+	}
+
+	expected := []string{
+		"43ff9ebfdccfa25e3921d9500db2f946d46a525fa08004af7f98976d9706cd5c",
+		"6fbb2561560558fd3dbb524904ab07f961c26bcff1a74d4d965fa00cb3002270",
+	}
+	for i, prv := range expected {
+		key, err := DeriveKey(seed, uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(key.PrivateKey) != prv {
+			t.Errorf("%d: expected %s, got %x", i, prv, key.PrivateKey)
+		}
+	}
+}
+
+func TestSeedFromMnemonicChecksum(t *testing.T) {
+	if _, err := SeedFromMnemonic(strings.Repeat("abandon ", 12)); err == nil {
+		t.Error("expected an error for a mnemonic with a bad checksum")
+	}
+}
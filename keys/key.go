@@ -0,0 +1,51 @@
+package ethkeys
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/obscuren/secp256k1-go"
+	"math/big"
+	"strings"
+)
+
+var curveOrder, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+
+var (
+	ErrKeyLength = errors.New("private key must be 32 bytes")
+	ErrKeyRange  = errors.New("private key must be greater than zero and less than the curve order")
+)
+
+// Returns whether k is a usable secp256k1 private key, 0 < k < n
+func validScalar(k *big.Int) bool {
+	return k.Sign() > 0 && k.Cmp(curveOrder) < 0
+}
+
+// Validates the private key and derives its public key from it
+func NewKeyFromPrivate(prv []byte) (*ethutil.Key, error) {
+	if len(prv) != 32 {
+		return nil, ErrKeyLength
+	}
+
+	if !validScalar(new(big.Int).SetBytes(prv)) {
+		return nil, ErrKeyRange
+	}
+
+	pub, err := secp256k1.GeneratePubKey(prv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ethutil.Key{PrivateKey: prv, PublicKey: pub}, nil
+}
+
+// Decodes and validates a hex encoded private key
+func NewKeyFromHex(str string) (*ethutil.Key, error) {
+	prv, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(str), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("private key isn't valid hex: %v", err)
+	}
+
+	return NewKeyFromPrivate(prv)
+}
@@ -0,0 +1,192 @@
+package ethkeys
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+	"io/ioutil"
+)
+
+// Key files are JSON documents used to move a single account between nodes:
+//
+//	{
+//		"version": 1,
+//		"label": "primary",
+//		"address": "<hex address>",
+//		"crypto": {
+//			"cipher": "aes-256-gcm",
+//			"kdf": "scrypt",
+//			"n": 262144, "r": 8, "p": 1,
+//			"salt": "<hex>", "nonce": "<hex>", "ciphertext": "<hex>"
+//		},
+//		"checksum": "<hex>"
+//	}
+//
+// The cipher text decrypts to the RLP encoded key pair [ prv, pub ] and the
+// address is authenticated along with it. Unencrypted key files carry a hex
+// encoded "privateKey" instead of "crypto". The checksum is the first four
+// bytes of the sha3 of the address followed by the cipher text, or private key,
+// and catches damaged files before a passphrase is asked for.
+const KeyFileVersion = 1
+
+const (
+	keyFileCipher = "aes-256-gcm"
+	keyFileKdf    = "scrypt"
+)
+
+var ErrKeyFileChecksum = errors.New("key file checksum mismatch")
+
+type KeyFile struct {
+	Version    int            `json:"version"`
+	Label      string         `json:"label,omitempty"`
+	Address    string         `json:"address"`
+	Crypto     *KeyFileCrypto `json:"crypto,omitempty"`
+	PrivateKey string         `json:"privateKey,omitempty"`
+	Checksum   string         `json:"checksum"`
+}
+
+type KeyFileCrypto struct {
+	Cipher     string `json:"cipher"`
+	Kdf        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	CipherText string `json:"ciphertext"`
+}
+
+// Creates a key file from the account. The key stays encrypted as it is in
+// the key ring, exporting doesn't require the passphrase.
+func NewKeyFile(acc *Account) *KeyFile {
+	c := acc.crypt
+	f := &KeyFile{
+		Version: KeyFileVersion,
+		Label:   acc.Label,
+		Address: acc.Hex(),
+		Crypto: &KeyFileCrypto{
+			Cipher:     keyFileCipher,
+			Kdf:        keyFileKdf,
+			N:          c.N,
+			R:          c.R,
+			P:          c.P,
+			Salt:       ethutil.Hex(c.Salt),
+			Nonce:      ethutil.Hex(c.Nonce),
+			CipherText: ethutil.Hex(c.CipherText),
+		},
+	}
+	f.Checksum = ethutil.Hex(keyFileChecksum(acc.Address, c.CipherText))
+
+	return f
+}
+
+func keyFileChecksum(addr, secret []byte) []byte {
+	return ethutil.Sha3Bin(append(append([]byte{}, addr...), secret...))[:4]
+}
+
+// Reads and validates the key file at the given path
+func ReadKeyFile(path string) (*KeyFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &KeyFile{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, err
+	}
+
+	if f.Version != KeyFileVersion {
+		return nil, fmt.Errorf("unsupported key file version %d", f.Version)
+	}
+
+	addr, err := hex.DecodeString(f.Address)
+	if err != nil || len(addr) != 20 {
+		return nil, fmt.Errorf("invalid key file address '%s'", f.Address)
+	}
+
+	var secret string
+	if f.Crypto != nil {
+		if f.Crypto.Cipher != keyFileCipher || f.Crypto.Kdf != keyFileKdf {
+			return nil, fmt.Errorf("unsupported key file encryption %s/%s", f.Crypto.Kdf, f.Crypto.Cipher)
+		}
+		secret = f.Crypto.CipherText
+	} else {
+		secret = f.PrivateKey
+	}
+
+	sdata, err := hex.DecodeString(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if checksum, _ := hex.DecodeString(f.Checksum); bytes.Compare(checksum, keyFileChecksum(addr, sdata)) != 0 {
+		return nil, ErrKeyFileChecksum
+	}
+
+	return f, nil
+}
+
+// Writes the key file, readable by the owner only
+func (f *KeyFile) Write(path string) error {
+	data, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), 0600)
+}
+
+func (f *KeyFile) IsEncrypted() bool {
+	return f.Crypto != nil
+}
+
+// Returns the encrypted account held by the key file. Use Account.Key to make
+// sure the passphrase is known and the key is valid.
+func (f *KeyFile) Account() (*Account, error) {
+	if f.Crypto == nil {
+		return nil, ErrPlainKey
+	}
+
+	salt, err := hex.DecodeString(f.Crypto.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := hex.DecodeString(f.Crypto.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherText, err := hex.DecodeString(f.Crypto.CipherText)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Crypt{N: f.Crypto.N, R: f.Crypto.R, P: f.Crypto.P, Salt: salt, Nonce: nonce, CipherText: cipherText}
+	addr, _ := hex.DecodeString(f.Address)
+
+	return &Account{Label: f.Label, Address: addr, crypt: c}, nil
+}
+
+// Returns the key of an unencrypted key file
+func (f *KeyFile) Key() (*ethutil.Key, error) {
+	if f.Crypto != nil {
+		return nil, errors.New("key file is encrypted")
+	}
+
+	key, err := NewKeyFromHex(f.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, _ := hex.DecodeString(f.Address)
+	if bytes.Compare(key.Address(), addr) != 0 {
+		return nil, ErrAddrMismatch
+	}
+
+	return key, nil
+}
@@ -0,0 +1,52 @@
+package ethkeys
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestKeyFileRoundTrip(t *testing.T) {
+	defer setupTest(t)()
+
+	acc, key := newTestAccount(t, "exported")
+
+	dir, err := ioutil.TempDir("", "keyfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := path.Join(dir, "key.json")
+	if err := NewKeyFile(acc).Write(file); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ReadKeyFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := f.Account()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.Label != "exported" || imported.Hex() != acc.Hex() {
+		t.Errorf("expected 'exported' %s, got '%s' %s", acc.Hex(), imported.Label, imported.Hex())
+	}
+	expectKey(t, imported, key)
+
+	// A damaged cipher text is caught before decrypting
+	damaged := []byte(f.Crypto.CipherText)
+	damaged[0] ^= 1
+	f.Crypto.CipherText = string(damaged)
+	if err := f.Write(file); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadKeyFile(file); err != ErrKeyFileChecksum {
+		t.Error("expected ErrKeyFileChecksum, got", err)
+	}
+}
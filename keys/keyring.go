@@ -0,0 +1,395 @@
+package ethkeys
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The key ring is stored in the database under the "KeyRing" key as
+//
+//	[ version, default address, [ [ label, address, crypt, archived ], ... ], seed, next ]
+//
+// The seed is the encrypted seed of a deterministic wallet, or empty, and next
+// is the index of the next account to derive from it.
+//
+// Older versions stored either a single RLP encoded key pair, [ prv, pub ],
+// in plain text or a single encrypted key, [ 1, address, crypt ]. Both are
+// converted when the key ring is loaded.
+var keyRingKey = []byte("KeyRing")
+
+const keyRingVersion = 3
+
+// Label given to the account converted from a single key key ring
+const DefaultLabel = "primary"
+
+var (
+	ErrNoKey          = errors.New("key ring is empty")
+	ErrUnknownAccount = errors.New("unknown account")
+	ErrDuplicate      = errors.New("account already exists")
+	ErrNoSeed         = errors.New("key ring isn't a deterministic wallet")
+)
+
+// Additional data authenticated along with the encrypted seed
+var seedData = []byte("seed")
+
+type KeyRing struct {
+	mut sync.Mutex
+
+	accounts []*Account
+	def      []byte
+
+	seed *Crypt
+	next uint32
+
+	// Decrypted keys of unlocked accounts by hex address
+	unlocked map[string]*unlocked
+}
+
+// Loads the key ring from the database
+func NewKeyRing() *KeyRing {
+	k := &KeyRing{unlocked: make(map[string]*unlocked)}
+
+	data, _ := ethutil.Config.Db.Get(keyRingKey)
+	if len(data) == 0 {
+		return k
+	}
+	decoder := ethutil.NewValueFromBytes(data)
+
+	switch {
+	case decoder.Len() == 2:
+		key := keyFromValue(decoder)
+		acc := &Account{Label: DefaultLabel, Address: key.Address(), plain: key}
+		k.accounts = []*Account{acc}
+	case decoder.Get(0).Uint() == 1:
+		acc := &Account{Label: DefaultLabel, Address: decoder.Get(1).Bytes(), crypt: &Crypt{}}
+		acc.crypt.RlpValueDecode(decoder.Get(2))
+		k.accounts = []*Account{acc}
+	default:
+		k.def = decoder.Get(1).Bytes()
+		it := decoder.Get(2)
+		for i := 0; i < it.Len(); i++ {
+			k.accounts = append(k.accounts, NewAccountFromValue(it.Get(i)))
+		}
+
+		if decoder.Len() > 3 && decoder.Get(3).Len() > 0 {
+			k.seed = &Crypt{}
+			k.seed.RlpValueDecode(decoder.Get(3))
+			k.next = uint32(decoder.Get(4).Uint())
+		}
+	}
+
+	return k
+}
+
+// Writes the key ring to the database. Must be called with the lock held.
+func (k *KeyRing) save() error {
+	accounts := make([]interface{}, len(k.accounts))
+	for i, acc := range k.accounts {
+		if acc.IsPlain() {
+			return ErrPlainKey
+		}
+		accounts[i] = acc.RlpValue()
+	}
+
+	var seed interface{} = ""
+	if k.seed != nil {
+		seed = k.seed.RlpValue()
+	}
+
+	ethutil.Config.Db.Put(keyRingKey, ethutil.Encode([]interface{}{uint64(keyRingVersion), k.def, accounts, seed, uint64(k.next)}))
+
+	return nil
+}
+
+func (k *KeyRing) Len() int {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	return len(k.accounts)
+}
+
+// Returns a copy of the account list
+func (k *KeyRing) Accounts() []*Account {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	accounts := make([]*Account, len(k.accounts))
+	copy(accounts, k.accounts)
+
+	return accounts
+}
+
+// Returns the account used when no account has been given explicitly. This
+// is the first account unless another one has been selected with SetDefault.
+func (k *KeyRing) Default() *Account {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	if len(k.accounts) == 0 {
+		return nil
+	}
+
+	if i := k.index(k.def); i >= 0 {
+		return k.accounts[i]
+	}
+
+	return k.accounts[0]
+}
+
+func (k *KeyRing) SetDefault(acc *Account) error {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	if k.index(acc.Address) < 0 {
+		return ErrUnknownAccount
+	}
+	k.def = acc.Address
+
+	return k.save()
+}
+
+func (k *KeyRing) index(addr []byte) int {
+	for i, acc := range k.accounts {
+		if bytes.Compare(acc.Address, addr) == 0 {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Looks up an account by label, hex address or position in the key ring
+func (k *KeyRing) Find(query string) (*Account, error) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	for _, acc := range k.accounts {
+		if acc.Label == query {
+			return acc, nil
+		}
+	}
+
+	if addr, err := hex.DecodeString(strings.TrimPrefix(query, "0x")); err == nil && len(addr) > 0 {
+		if i := k.index(addr); i >= 0 {
+			return k.accounts[i], nil
+		}
+	}
+
+	if i, err := strconv.Atoi(query); err == nil && i >= 0 && i < len(k.accounts) {
+		return k.accounts[i], nil
+	}
+
+	return nil, fmt.Errorf("%v: %s", ErrUnknownAccount, query)
+}
+
+// Adds the account to the key ring. Labels and addresses must be unique.
+func (k *KeyRing) Add(acc *Account) error {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	for _, a := range k.accounts {
+		if a.Label == acc.Label || bytes.Compare(a.Address, acc.Address) == 0 {
+			return ErrDuplicate
+		}
+	}
+	k.accounts = append(k.accounts, acc)
+
+	return k.save()
+}
+
+// Makes the account the new default. The previous default account is archived
+// under a new label but stays in the key ring so it can still sign, e.g. to
+// move its funds to the new account. Returns the archived account, if any.
+func (k *KeyRing) Rotate(acc *Account) (*Account, error) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	var old *Account
+	if len(k.accounts) > 0 {
+		old = k.accounts[0]
+		if i := k.index(k.def); i >= 0 {
+			old = k.accounts[i]
+		}
+	}
+
+	for _, a := range k.accounts {
+		if bytes.Compare(a.Address, acc.Address) == 0 || (a.Label == acc.Label && a != old) {
+			return nil, ErrDuplicate
+		}
+	}
+
+	if old != nil {
+		old.Label = fmt.Sprintf("%s-%x", old.Label, old.Address[:4])
+		old.Archived = true
+	}
+	k.accounts = append(k.accounts, acc)
+	k.def = acc.Address
+
+	return old, k.save()
+}
+
+// Replaces the account with the same address in a single step, keeping its
+// place and whether it's the default account. Labels must stay unique.
+func (k *KeyRing) Replace(acc *Account) error {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	i := k.index(acc.Address)
+	if i < 0 {
+		return ErrUnknownAccount
+	}
+
+	for j, a := range k.accounts {
+		if j != i && a.Label == acc.Label {
+			return ErrDuplicate
+		}
+	}
+
+	old := k.accounts[i]
+	k.accounts[i] = acc
+	if err := k.save(); err != nil {
+		k.accounts[i] = old
+		return err
+	}
+	k.lock(acc.Hex())
+
+	return nil
+}
+
+// Removes the account from the key ring. If it was the default account the
+// first remaining account becomes the default.
+func (k *KeyRing) Remove(acc *Account) error {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	i := k.index(acc.Address)
+	if i < 0 {
+		return ErrUnknownAccount
+	}
+	k.accounts = append(k.accounts[:i], k.accounts[i+1:]...)
+	k.lock(acc.Hex())
+
+	if bytes.Compare(k.def, acc.Address) == 0 {
+		k.def = nil
+	}
+
+	return k.save()
+}
+
+// Returns whether the key ring contains private keys stored in plain text. Such
+// key rings predate encryption and should be encrypted using Migrate.
+func (k *KeyRing) IsPlain() bool {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	for _, acc := range k.accounts {
+		if acc.IsPlain() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Encrypts all plain text keys with the given passphrase
+func (k *KeyRing) Migrate(passphrase string) error {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	for i, acc := range k.accounts {
+		if !acc.IsPlain() {
+			continue
+		}
+
+		encrypted, err := NewAccount(acc.Label, acc.plain, passphrase)
+		if err != nil {
+			return err
+		}
+		k.accounts[i] = encrypted
+	}
+
+	return k.save()
+}
+
+// Returns whether the key ring holds the seed of a deterministic wallet
+func (k *KeyRing) IsHD() bool {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	return k.seed != nil
+}
+
+// Encrypts and stores the deterministic wallet seed, replacing any previous
+// seed. Derivation restarts at the first account.
+func (k *KeyRing) SetSeed(seed []byte, passphrase string) error {
+	crypt, err := Encrypt(seed, passphrase, seedData)
+	if err != nil {
+		return err
+	}
+
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	k.seed = crypt
+	k.next = 0
+
+	return k.save()
+}
+
+// Derives the next account from the seed and adds it to the key ring. The
+// account is encrypted with the same passphrase as the seed. An empty label
+// is replaced by one based on the derivation index.
+func (k *KeyRing) DeriveAccount(label, passphrase string) (*Account, error) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	if k.seed == nil {
+		return nil, ErrNoSeed
+	}
+
+	seed, err := k.seed.Decrypt(passphrase, seedData)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range k.accounts {
+		if len(label) > 0 && a.Label == label {
+			return nil, ErrDuplicate
+		}
+	}
+
+	for {
+		index := k.next
+		k.next++
+
+		key, err := DeriveKey(seed, index)
+		if err == ErrInvalidChild {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if len(label) == 0 {
+			label = fmt.Sprintf("hd/%d", index)
+		}
+
+		// Accounts restored from the same seed may already be present
+		if i := k.index(key.Address()); i >= 0 {
+			return k.accounts[i], k.save()
+		}
+
+		acc, err := NewAccount(label, key, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		k.accounts = append(k.accounts, acc)
+
+		return acc, k.save()
+	}
+}
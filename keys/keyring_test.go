@@ -0,0 +1,136 @@
+package ethkeys
+
+import (
+	"github.com/ethereum/eth-go/ethdb"
+	"github.com/ethereum/eth-go/ethutil"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const (
+	testKey  = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testPass = "correct horse"
+)
+
+// Cheap scrypt parameters and a fresh in memory database for every test
+func setupTest(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "ethkeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ethutil.ReadConfig(dir)
+
+	db, _ := ethdb.NewMemDatabase()
+	ethutil.Config.Db = db
+
+	n := ScryptN
+	ScryptN = 1 << 4
+
+	return func() {
+		ScryptN = n
+		os.RemoveAll(dir)
+	}
+}
+
+func newTestAccount(t *testing.T, label string) (*Account, *ethutil.Key) {
+	key, err := NewKeyFromHex(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acc, err := NewAccount(label, key, testPass)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return acc, key
+}
+
+func expectKey(t *testing.T, acc *Account, key *ethutil.Key) {
+	decrypted, err := acc.Key(testPass)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(decrypted.PrivateKey) != string(key.PrivateKey) {
+		t.Errorf("expected the private key %x, got %x", key.PrivateKey, decrypted.PrivateKey)
+	}
+}
+
+// Reloads the key ring and checks it was saved as the current version
+func reloadKeyRing(t *testing.T) *KeyRing {
+	data, _ := ethutil.Config.Db.Get(keyRingKey)
+	if version := ethutil.NewValueFromBytes(data).Get(0).Uint(); version != keyRingVersion {
+		t.Fatalf("expected the key ring to be saved as version %d, got %d", keyRingVersion, version)
+	}
+
+	return NewKeyRing()
+}
+
+func TestKeyRingMigratePlain(t *testing.T) {
+	defer setupTest(t)()
+
+	key, _ := NewKeyFromHex(testKey)
+	ethutil.Config.Db.Put(keyRingKey, ethutil.Encode([]interface{}{key.PrivateKey, key.PublicKey}))
+
+	keyRing := NewKeyRing()
+	if !keyRing.IsPlain() || keyRing.Len() != 1 {
+		t.Fatal("expected a key ring with a single plain key")
+	}
+
+	if _, err := keyRing.Default().Key(testPass); err != ErrPlainKey {
+		t.Error("expected ErrPlainKey, got", err)
+	}
+
+	if err := keyRing.Migrate(testPass); err != nil {
+		t.Fatal(err)
+	}
+
+	keyRing = reloadKeyRing(t)
+	acc := keyRing.Default()
+	if keyRing.IsPlain() || acc.Label != DefaultLabel {
+		t.Fatalf("expected the encrypted account '%s', got '%s' (plain %v)", DefaultLabel, acc.Label, keyRing.IsPlain())
+	}
+	expectKey(t, acc, key)
+}
+
+func TestKeyRingMigrateEncrypted(t *testing.T) {
+	defer setupTest(t)()
+
+	acc, key := newTestAccount(t, "")
+	ethutil.Config.Db.Put(keyRingKey, ethutil.Encode([]interface{}{uint64(1), acc.Address, acc.crypt.RlpValue()}))
+
+	keyRing := NewKeyRing()
+	if keyRing.Len() != 1 || keyRing.Default().Label != DefaultLabel {
+		t.Fatal("expected the single key to become the default account")
+	}
+
+	// Any change saves the current version
+	if err := keyRing.SetDefault(keyRing.Default()); err != nil {
+		t.Fatal(err)
+	}
+	expectKey(t, reloadKeyRing(t).Default(), key)
+}
+
+func TestKeyRingMigrateWithoutSeed(t *testing.T) {
+	defer setupTest(t)()
+
+	acc, key := newTestAccount(t, "main")
+	ethutil.Config.Db.Put(keyRingKey, ethutil.Encode([]interface{}{uint64(2), acc.Address, []interface{}{acc.RlpValue()}}))
+
+	keyRing := NewKeyRing()
+	if keyRing.IsHD() || keyRing.Len() != 1 {
+		t.Fatal("expected a single account and no seed")
+	}
+
+	if err := keyRing.SetDefault(keyRing.Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	keyRing = reloadKeyRing(t)
+	if acc := keyRing.Default(); acc.Label != "main" {
+		t.Errorf("expected the account 'main', got '%s'", acc.Label)
+	}
+	expectKey(t, keyRing.Default(), key)
+}
@@ -0,0 +1,104 @@
+package ethkeys
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+	"time"
+)
+
+// Accounts are locked by default. Signing requires unlocking the account
+// first, either for a limited time or for a single signature.
+var ErrLocked = errors.New("account is locked")
+
+type unlocked struct {
+	key  *ethutil.Key
+	once bool
+}
+
+// Decrypts the account's key and keeps it available for signing for the given
+// duration. A zero duration unlocks the account for a single signature.
+func (k *KeyRing) Unlock(acc *Account, passphrase string, d time.Duration) error {
+	key, err := acc.Key(passphrase)
+	if err != nil {
+		return err
+	}
+
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	addr := acc.Hex()
+	k.lock(addr)
+
+	u := &unlocked{key: key, once: d == 0}
+	k.unlocked[addr] = u
+	if d > 0 {
+		time.AfterFunc(d, func() {
+			k.mut.Lock()
+			defer k.mut.Unlock()
+
+			// The account may have been unlocked again in the mean time
+			if k.unlocked[addr] == u {
+				k.lock(addr)
+			}
+		})
+	}
+
+	return nil
+}
+
+// Must be called with the lock held
+func (k *KeyRing) lock(addr string) {
+	if u, ok := k.unlocked[addr]; ok {
+		for i := range u.key.PrivateKey {
+			u.key.PrivateKey[i] = 0
+		}
+		delete(k.unlocked, addr)
+	}
+}
+
+func (k *KeyRing) Lock(acc *Account) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	k.lock(acc.Hex())
+}
+
+func (k *KeyRing) IsUnlocked(acc *Account) bool {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	_, ok := k.unlocked[acc.Hex()]
+
+	return ok
+}
+
+// Returns a copy of the key of an unlocked account. Accounts unlocked for a
+// single signature are locked again.
+func (k *KeyRing) UnlockedKey(acc *Account) (*ethutil.Key, error) {
+	return k.unlockedKey(acc, true)
+}
+
+// Returns a copy of the key of an unlocked account without using up a single
+// signature unlock, for signatures which are never sent such as dry runs
+func (k *KeyRing) PeekKey(acc *Account) (*ethutil.Key, error) {
+	return k.unlockedKey(acc, false)
+}
+
+func (k *KeyRing) unlockedKey(acc *Account, use bool) (*ethutil.Key, error) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	addr := acc.Hex()
+	u, ok := k.unlocked[addr]
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", ErrLocked, acc.Label)
+	}
+
+	key := &ethutil.Key{PrivateKey: append([]byte{}, u.key.PrivateKey...), PublicKey: u.key.PublicKey}
+	if u.once && use {
+		k.lock(addr)
+	}
+
+	return key, nil
+}
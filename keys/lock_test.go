@@ -0,0 +1,66 @@
+package ethkeys
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnlockExpires(t *testing.T) {
+	defer setupTest(t)()
+
+	keyRing := NewKeyRing()
+	acc, _ := newTestAccount(t, "main")
+	if err := keyRing.Add(acc); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := keyRing.Unlock(acc, "wrong", time.Second); err != ErrDecrypt {
+		t.Error("expected ErrDecrypt, got", err)
+	}
+
+	if err := keyRing.Unlock(acc, testPass, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unlocked for a duration, keys can be used more than once
+	for i := 0; i < 2; i++ {
+		if _, err := keyRing.UnlockedKey(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if keyRing.IsUnlocked(acc) {
+		t.Error("expected the account to be locked again")
+	}
+
+	if _, err := keyRing.UnlockedKey(acc); err == nil {
+		t.Error("expected the key of a locked account to be unavailable")
+	}
+}
+
+func TestUnlockOnce(t *testing.T) {
+	defer setupTest(t)()
+
+	keyRing := NewKeyRing()
+	acc, _ := newTestAccount(t, "main")
+	if err := keyRing.Add(acc); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := keyRing.Unlock(acc, testPass, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keyRing.PeekKey(acc); err != nil {
+		t.Fatal("expected peeking not to use up the unlock:", err)
+	}
+
+	if _, err := keyRing.UnlockedKey(acc); err != nil {
+		t.Fatal(err)
+	}
+
+	if keyRing.IsUnlocked(acc) {
+		t.Error("expected the account to be locked after a single signature")
+	}
+}
@@ -0,0 +1,49 @@
+package ethkeys
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/obscuren/secp256k1-go"
+)
+
+// Messages aren't signed directly but as the hash of
+//
+//	"\x19Ethereum Signed Message:\n" + decimal length + message
+//
+// A transaction is signed over the hash of its RLP encoding, which always
+// starts with a list prefix (>= 0xc0), so a message signature can never be
+// replayed as a transaction signature.
+const MessagePrefix = "\x19Ethereum Signed Message:\n"
+
+var (
+	ErrSignatureLength = errors.New("signature must be 65 bytes")
+	ErrSignerMismatch  = errors.New("message wasn't signed by the given address")
+)
+
+func MessageHash(msg []byte) []byte {
+	return ethutil.Sha3Bin([]byte(fmt.Sprintf("%s%d%s", MessagePrefix, len(msg), msg)))
+}
+
+// Signs the message, returns the 65 byte [ r, s, v ] signature
+func SignMessage(key *ethutil.Key, msg []byte) ([]byte, error) {
+	return secp256k1.Sign(MessageHash(msg), key.PrivateKey)
+}
+
+// Recovers the address of the key which signed the message
+func RecoverMessageSigner(msg, sig []byte) ([]byte, error) {
+	if len(sig) != 65 {
+		return nil, ErrSignatureLength
+	}
+
+	pub, err := secp256k1.RecoverPubkey(MessageHash(msg), sig)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pub) != 65 || pub[0] != 4 {
+		return nil, errors.New("unable to recover the public key")
+	}
+
+	return ethutil.Sha3Bin(pub[1:])[12:], nil
+}
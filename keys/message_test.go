@@ -0,0 +1,35 @@
+package ethkeys
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSignMessage(t *testing.T) {
+	key, err := NewKeyFromHex(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := SignMessage(key, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := RecoverMessageSigner([]byte("hello"), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Compare(signer, key.Address()) != 0 {
+		t.Errorf("expected the signer %x, got %x", key.Address(), signer)
+	}
+
+	if signer, err := RecoverMessageSigner([]byte("hello!"), sig); err == nil && bytes.Compare(signer, key.Address()) == 0 {
+		t.Error("expected another message not to recover the signer")
+	}
+
+	if _, err := RecoverMessageSigner([]byte("hello"), sig[1:]); err != ErrSignatureLength {
+		t.Error("expected ErrSignatureLength, got", err)
+	}
+}
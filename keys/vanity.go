@@ -0,0 +1,89 @@
+package ethkeys
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/obscuren/secp256k1-go"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// A vanity pattern matches addresses starting and/or ending with the given
+// hex strings
+type VanityPattern struct {
+	Prefix, Suffix string
+}
+
+func NewVanityPattern(prefix, suffix string) (*VanityPattern, error) {
+	p := &VanityPattern{Prefix: strings.ToLower(prefix), Suffix: strings.ToLower(suffix)}
+	for _, str := range []string{p.Prefix, p.Suffix} {
+		if strings.Trim(str, "0123456789abcdef") != "" {
+			return nil, fmt.Errorf("'%s' isn't a hex string", str)
+		}
+	}
+
+	if len(p.Prefix)+len(p.Suffix) > 40 {
+		return nil, fmt.Errorf("pattern is longer than an address")
+	}
+
+	return p, nil
+}
+
+func (p *VanityPattern) Matches(addr []byte) bool {
+	str := hex.EncodeToString(addr)
+
+	return strings.HasPrefix(str, p.Prefix) && strings.HasSuffix(str, p.Suffix)
+}
+
+// Returns the expected number of keys to try before finding a match
+func (p *VanityPattern) Difficulty() float64 {
+	return math.Pow(16, float64(len(p.Prefix)+len(p.Suffix)))
+}
+
+func (p *VanityPattern) String() string {
+	return p.Prefix + "..." + p.Suffix
+}
+
+// Generates key pairs on the given number of workers until one of them matches
+// the pattern. Every key tried is counted in attempts. Returns nil when the
+// search is cancelled by closing quit.
+func SearchVanity(p *VanityPattern, workers int, quit chan struct{}, attempts *uint64) *ethutil.Key {
+	found := make(chan *ethutil.Key, workers)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				pub, prv := secp256k1.GenerateKeyPair()
+				key := &ethutil.Key{PrivateKey: prv, PublicKey: pub}
+				atomic.AddUint64(attempts, 1)
+				if p.Matches(key.Address()) {
+					found <- key
+					return
+				}
+			}
+		}()
+	}
+
+	var key *ethutil.Key
+	select {
+	case key = <-found:
+	case <-quit:
+	}
+	close(stop)
+	wg.Wait()
+
+	return key
+}
@@ -0,0 +1,120 @@
+package ethkeys
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ethereum/eth-go/ethutil"
+	"sync"
+)
+
+// Watch-only addresses are tracked like accounts but have no private key and
+// can't be spent from. They're stored in the database under the "WatchList"
+// key as [ [ label, address ], ... ].
+var watchKey = []byte("WatchList")
+
+var ErrAddrLength = errors.New("address must be 20 bytes")
+
+type Watched struct {
+	Label   string
+	Address []byte
+}
+
+func (w *Watched) Hex() string {
+	return ethutil.Hex(w.Address)
+}
+
+type WatchList struct {
+	mut sync.Mutex
+
+	entries []*Watched
+}
+
+// Loads the watch list from the database
+func NewWatchList() *WatchList {
+	w := &WatchList{}
+
+	data, _ := ethutil.Config.Db.Get(watchKey)
+	if len(data) == 0 {
+		return w
+	}
+
+	decoder := ethutil.NewValueFromBytes(data)
+	for i := 0; i < decoder.Len(); i++ {
+		it := decoder.Get(i)
+		w.entries = append(w.entries, &Watched{Label: it.Get(0).Str(), Address: it.Get(1).Bytes()})
+	}
+
+	return w
+}
+
+// Must be called with the lock held
+func (w *WatchList) save() {
+	entries := make([]interface{}, len(w.entries))
+	for i, e := range w.entries {
+		entries[i] = []interface{}{e.Label, e.Address}
+	}
+
+	ethutil.Config.Db.Put(watchKey, ethutil.Encode(entries))
+}
+
+// Returns a copy of the watched addresses
+func (w *WatchList) Entries() []*Watched {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	entries := make([]*Watched, len(w.entries))
+	copy(entries, w.entries)
+
+	return entries
+}
+
+// Returns the entry of the address or nil if it isn't watched
+func (w *WatchList) Get(addr []byte) *Watched {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	for _, e := range w.entries {
+		if bytes.Compare(e.Address, addr) == 0 {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func (w *WatchList) Add(label string, addr []byte) (*Watched, error) {
+	if len(addr) != 20 {
+		return nil, ErrAddrLength
+	}
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	for _, e := range w.entries {
+		if bytes.Compare(e.Address, addr) == 0 {
+			return nil, ErrDuplicate
+		}
+	}
+
+	e := &Watched{Label: label, Address: addr}
+	w.entries = append(w.entries, e)
+	w.save()
+
+	return e, nil
+}
+
+func (w *WatchList) Remove(addr []byte) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	for i, e := range w.entries {
+		if bytes.Compare(e.Address, addr) == 0 {
+			w.entries = append(w.entries[:i], w.entries[i+1:]...)
+			w.save()
+
+			return nil
+		}
+	}
+
+	return ErrUnknownAccount
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"github.com/ethereum/eth-go"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethwire"
+	"log"
+	"sync"
+)
+
+// Fake block mining. Once started it keeps mining and broadcasting blocks
+// with the pending transactions until stopped.
+type Miner struct {
+	ethereum *eth.Ethereum
+	coinbase []byte
+
+	mut  sync.Mutex
+	quit chan struct{}
+}
+
+func NewMiner(ethereum *eth.Ethereum, coinbase []byte) *Miner {
+	return &Miner{ethereum: ethereum, coinbase: coinbase}
+}
+
+func (m *Miner) Mining() bool {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	return m.quit != nil
+}
+
+func (m *Miner) Start() {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.quit != nil {
+		return
+	}
+	m.quit = make(chan struct{})
+	log.Printf("Miner started (coinbase %x)\n", m.coinbase)
+
+	go m.mine(m.quit)
+}
+
+// Stops mining. The block being searched is dropped once its nonce is found.
+func (m *Miner) Stop() {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.quit != nil {
+		close(m.quit)
+		m.quit = nil
+		log.Println("Miner stopped")
+	}
+}
+
+func (m *Miner) mine(quit chan struct{}) {
+	pow := &ethchain.EasyPow{}
+	for {
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
+		txs := m.ethereum.TxPool.Flush()
+		// Create a new block which we're going to mine
+		block := m.ethereum.BlockManager.BlockChain().NewBlock(m.coinbase, txs)
+		// Apply all transactions to the block
+		m.ethereum.BlockManager.ApplyTransactions(block, block.Transactions())
+
+		m.ethereum.BlockManager.AccumelateRewards(block, block)
+
+		// Search the nonce
+		block.Nonce = pow.Search(block)
+
+		select {
+		case <-quit:
+			// Hand the transactions back for whoever mines next
+			for _, tx := range txs {
+				m.ethereum.TxPool.QueueTransaction(tx)
+			}
+			return
+		default:
+		}
+
+		m.ethereum.Broadcast(ethwire.MsgBlockTy, []interface{}{block.Value().Val})
+		err := m.ethereum.BlockManager.ProcessBlock(block)
+		if err != nil {
+			log.Println(err)
+		} else {
+			log.Println("\n+++++++ MINED BLK +++++++\n", m.ethereum.BlockManager.BlockChain().CurrentBlock)
+		}
+	}
+}
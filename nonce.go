@@ -0,0 +1,115 @@
+package main
+
+import (
+	"sync"
+)
+
+// The state only counts the transactions of an account which made it into a
+// block. Transactions sent in a row would all get the same nonce from it, so
+// the nonces handed out by the node are tracked until the state catches up.
+// Sessions sending at the same time reserve their nonces, a reserved nonce is
+// never handed out twice unless it's released again.
+type NonceTracker struct {
+	mut  sync.Mutex
+	next map[string]uint64
+	// Nonces released below next, handed out again first
+	released map[string]map[uint64]bool
+}
+
+// Shared by all console sessions of the node
+var Nonces = NewNonceTracker()
+
+func NewNonceTracker() *NonceTracker {
+	return &NonceTracker{next: make(map[string]uint64), released: make(map[string]map[uint64]bool)}
+}
+
+// Must be called with the lock held
+func (n *NonceTracker) peek(addr string, stateNonce uint64) uint64 {
+	lowest, found := uint64(0), false
+	for nonce := range n.released[addr] {
+		if nonce < stateNonce {
+			delete(n.released[addr], nonce)
+		} else if !found || nonce < lowest {
+			lowest, found = nonce, true
+		}
+	}
+	if found {
+		return lowest
+	}
+
+	if next := n.next[addr]; next > stateNonce {
+		return next
+	}
+
+	return stateNonce
+}
+
+// Returns the nonce of the next transaction of the address, given the nonce
+// of its state, without reserving it
+func (n *NonceTracker) Next(addr []byte, stateNonce uint64) uint64 {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	return n.peek(string(addr), stateNonce)
+}
+
+// Hands out the nonce of the next transaction of the address, given the nonce
+// of its state. It has to be released if the transaction isn't sent.
+func (n *NonceTracker) Reserve(addr []byte, stateNonce uint64) uint64 {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	nonce := n.peek(string(addr), stateNonce)
+	if n.released[string(addr)][nonce] {
+		delete(n.released[string(addr)], nonce)
+	} else {
+		n.next[string(addr)] = nonce + 1
+	}
+
+	return nonce
+}
+
+// Gives back a reserved nonce whose transaction wasn't sent
+func (n *NonceTracker) Release(addr []byte, nonce uint64) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	key := string(addr)
+	if nonce+1 != n.next[key] {
+		if n.released[key] == nil {
+			n.released[key] = make(map[uint64]bool)
+		}
+		n.released[key][nonce] = true
+
+		return
+	}
+
+	// The last nonce handed out, along with the released ones right below it
+	n.next[key] = nonce
+	for n.next[key] > 0 && n.released[key][n.next[key]-1] {
+		delete(n.released[key], n.next[key]-1)
+		n.next[key]--
+	}
+}
+
+// Records a transaction sent with the nonce
+func (n *NonceTracker) Use(addr []byte, nonce uint64) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	delete(n.released[string(addr)], nonce)
+	if nonce >= n.next[string(addr)] {
+		n.next[string(addr)] = nonce + 1
+	}
+}
+
+// Forgets the nonces handed out for the address, the next one is taken from
+// its state again. Needed when a queued transaction was dropped and never
+// makes it into a block, the nonces after it would wait for it forever.
+func (n *NonceTracker) Reset(addr []byte) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	delete(n.next, string(addr))
+	delete(n.released, string(addr))
+}
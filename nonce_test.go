@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNonceReserve(t *testing.T) {
+	n := NewNonceTracker()
+	addr := []byte{1}
+
+	if a, b := n.Reserve(addr, 3), n.Reserve(addr, 3); a != 3 || b != 4 {
+		t.Fatalf("expected 3 and 4, got %d and %d", a, b)
+	}
+
+	// Released in the middle, handed out again before the next one
+	n.Release(addr, 3)
+	if nonce := n.Reserve(addr, 3); nonce != 3 {
+		t.Errorf("expected the released 3, got %d", nonce)
+	}
+	if nonce := n.Reserve(addr, 3); nonce != 5 {
+		t.Errorf("expected 5, got %d", nonce)
+	}
+
+	n.Release(addr, 4)
+	n.Release(addr, 5)
+	if nonce := n.Next(addr, 3); nonce != 4 {
+		t.Errorf("expected 4 after releasing the last two, got %d", nonce)
+	}
+
+	// The state caught up
+	if nonce := n.Reserve(addr, 7); nonce != 7 {
+		t.Errorf("expected the state nonce 7, got %d", nonce)
+	}
+}
+
+func TestNonceReset(t *testing.T) {
+	n := NewNonceTracker()
+	addr := []byte{1}
+
+	n.Use(addr, 5)
+	if nonce := n.Next(addr, 2); nonce != 6 {
+		t.Fatalf("expected 6, got %d", nonce)
+	}
+
+	n.Reset(addr)
+	if nonce := n.Reserve(addr, 2); nonce != 2 {
+		t.Errorf("expected the state nonce after a reset, got %d", nonce)
+	}
+}
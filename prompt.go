@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"code.google.com/p/go.crypto/ssh/terminal"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Exit codes of the key operations (-genaddr, -import, ...)
+const (
+	ExitDone    = 0
+	ExitFailed  = 1
+	ExitAborted = 2
+)
+
+var (
+	ErrAborted  = errors.New("aborted")
+	ErrNoPrompt = errors.New("prompting is disabled (-no-prompt)")
+)
+
+// A Prompter asks the user questions. All input is read from a single
+// reader which makes prompts testable and scriptable.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// Reads a passphrase without echoing it. Nil reads a plain line.
+	readPassword func() ([]byte, error)
+	// Shows the prompt and reads a line in place of in, if set
+	lines func(prompt string) (string, error)
+	// Reads a passphrase through the line reader without echoing it
+	passwords func(prompt string) (string, error)
+
+	// Answer every confirmation with yes
+	AssumeYes bool
+	// Never read from the input. Confirmations are answered with no, unless
+	// AssumeYes is set, and passphrases must be given up front.
+	NoPrompt bool
+	// Used instead of asking for passphrases, if set
+	Passphrase string
+	// Returned instead of asking for passphrases, if set
+	PassphraseErr error
+}
+
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out}
+}
+
+// Creates a prompter reading from stdin. Passphrases aren't echoed when stdin
+// is a terminal.
+func NewTerminalPrompter() *Prompter {
+	p := NewPrompter(os.Stdin, os.Stdout)
+
+	fd := int(os.Stdin.Fd())
+	if terminal.IsTerminal(fd) {
+		p.readPassword = func() ([]byte, error) {
+			pass, err := terminal.ReadPassword(fd)
+			fmt.Fprintln(p.out)
+
+			return pass, err
+		}
+	}
+
+	return p
+}
+
+// The prompter used by the key and account operations
+var Prompt = NewTerminalPrompter()
+
+// Reads the passphrase from the first line of the given file
+func ReadPassphraseFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r"), nil
+}
+
+// Lets a line editor which reads the same input, such as the console's, read
+// the answers. Its buffer would otherwise hold input meant for the prompter.
+// Nil goes back to reading the input directly.
+func (p *Prompter) SetLineReader(lines func(prompt string) (string, error)) {
+	p.lines = lines
+}
+
+// Lets the line editor set with SetLineReader read passphrases as well, like
+// liner's PasswordPrompt. While a line reader is set passphrases are never
+// read from the terminal directly.
+func (p *Prompter) SetPasswordReader(passwords func(prompt string) (string, error)) {
+	p.passwords = passwords
+}
+
+func (p *Prompter) readLine(prompt string) (string, error) {
+	if p.lines != nil {
+		str, err := p.lines(prompt)
+		return strings.TrimSpace(str), err
+	}
+	fmt.Fprint(p.out, prompt)
+
+	str, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || len(str) == 0) {
+		return "", err
+	}
+
+	return strings.TrimSpace(str), nil
+}
+
+// Reads a single line
+func (p *Prompter) ReadLine(prompt string) (string, error) {
+	if p.NoPrompt {
+		return "", ErrNoPrompt
+	}
+
+	return p.readLine(prompt)
+}
+
+// Asks a yes or no question until it's answered. Failing to read an answer
+// counts as no.
+func (p *Prompter) Confirm(question string) bool {
+	if p.AssumeYes || p.NoPrompt {
+		return p.AssumeYes
+	}
+
+	prompt := question + " (y/n) "
+	for {
+		r, err := p.readLine(prompt)
+		if err != nil {
+			return false
+		}
+
+		if r == "y" || r == "n" {
+			return r == "y"
+		}
+		prompt = "Yes or no? "
+	}
+}
+
+// Reads a passphrase, without echoing it if possible
+func (p *Prompter) ReadPassphrase(prompt string) (string, error) {
+	if len(p.Passphrase) > 0 {
+		return p.Passphrase, nil
+	}
+
+	if p.PassphraseErr != nil {
+		return "", p.PassphraseErr
+	}
+
+	if p.NoPrompt {
+		return "", ErrNoPrompt
+	}
+
+	if p.lines != nil && p.passwords != nil {
+		return p.passwords(prompt)
+	}
+
+	if p.readPassword == nil || p.lines != nil {
+		return p.readLine(prompt)
+	}
+	fmt.Fprint(p.out, prompt)
+
+	pass, err := p.readPassword()
+	if err != nil {
+		return "", err
+	}
+
+	return string(pass), nil
+}
+
+// Asks for a new passphrase until a non empty one is entered twice
+func (p *Prompter) ReadNewPassphrase() (string, error) {
+	if len(p.Passphrase) > 0 {
+		return p.Passphrase, nil
+	}
+
+	for {
+		pass, err := p.ReadPassphrase("New passphrase: ")
+		if err != nil {
+			return "", err
+		}
+
+		if len(pass) == 0 {
+			fmt.Fprintln(p.out, "The passphrase can't be empty")
+			continue
+		}
+
+		again, err := p.ReadPassphrase("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+
+		if pass == again {
+			return pass, nil
+		}
+		fmt.Fprintln(p.out, "Passphrases do not match")
+	}
+}
+
+func (p *Prompter) Printf(format string, v ...interface{}) {
+	fmt.Fprintf(p.out, format, v...)
+}
+
+func (p *Prompter) Println(v ...interface{}) {
+	fmt.Fprintln(p.out, v...)
+}
+
+func ReadLine(prompt string) (string, error) {
+	return Prompt.ReadLine(prompt)
+}
+
+func Confirm(question string) bool {
+	return Prompt.Confirm(question)
+}
+
+func ReadPassphrase(prompt string) (string, error) {
+	return Prompt.ReadPassphrase(prompt)
+}
+
+func ReadNewPassphrase() (string, error) {
+	return Prompt.ReadNewPassphrase()
+}
+
+// Exits with the code matching the outcome of a key operation
+func Exit(err error) {
+	switch err {
+	case nil:
+		os.Exit(ExitDone)
+	case ErrAborted:
+		fmt.Println(err)
+		os.Exit(ExitAborted)
+	default:
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(ExitFailed)
+	}
+}
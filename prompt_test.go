@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func newTestPrompter(input string) *Prompter {
+	return NewPrompter(strings.NewReader(input), new(bytes.Buffer))
+}
+
+func TestPrompterConfirm(t *testing.T) {
+	if !newTestPrompter("y\n").Confirm("sure?") {
+		t.Error("expected yes")
+	}
+
+	if newTestPrompter("n\n").Confirm("sure?") {
+		t.Error("expected no")
+	}
+
+	if !newTestPrompter("maybe\n\ny\n").Confirm("sure?") {
+		t.Error("expected yes after retrying")
+	}
+
+	if newTestPrompter("").Confirm("sure?") {
+		t.Error("expected no on EOF")
+	}
+}
+
+func TestPrompterNoPrompt(t *testing.T) {
+	p := newTestPrompter("y\n")
+	p.NoPrompt = true
+	if p.Confirm("sure?") {
+		t.Error("expected no without prompting")
+	}
+
+	if _, err := p.ReadPassphrase("pass: "); err != ErrNoPrompt {
+		t.Error("expected ErrNoPrompt, got", err)
+	}
+
+	p.AssumeYes = true
+	if !p.Confirm("sure?") {
+		t.Error("expected yes with AssumeYes")
+	}
+
+	p.Passphrase = "secret"
+	if pass, err := p.ReadNewPassphrase(); err != nil || pass != "secret" {
+		t.Error("expected the preset passphrase, got", pass, err)
+	}
+}
+
+func TestPrompterNewPassphrase(t *testing.T) {
+	pass, err := newTestPrompter("\nfoo\nbar\nfoo\nfoo\n").ReadNewPassphrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pass != "foo" {
+		t.Errorf("expected 'foo', got '%s'", pass)
+	}
+
+	if _, err := newTestPrompter("foo\n").ReadNewPassphrase(); err == nil {
+		t.Error("expected an error when the input ends")
+	}
+}
+
+func TestPrompterPassphraseErr(t *testing.T) {
+	p := newTestPrompter("y\n")
+	p.PassphraseErr = ErrAborted
+	if _, err := p.ReadPassphrase("pass: "); err != ErrAborted {
+		t.Error("expected the preset error, got", err)
+	}
+
+	if !p.Confirm("sure?") {
+		t.Error("expected confirmations to still be asked")
+	}
+}
+
+func TestPrompterLineReader(t *testing.T) {
+	p := newTestPrompter("n\n")
+
+	var prompts []string
+	answers := []string{"maybe", " y "}
+	p.SetLineReader(func(prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		answer := answers[0]
+		answers = answers[1:]
+
+		return answer, nil
+	})
+
+	if !p.Confirm("sure?") {
+		t.Error("expected the answer of the line reader")
+	}
+
+	if len(prompts) != 2 || prompts[0] != "sure? (y/n) " || prompts[1] != "Yes or no? " {
+		t.Errorf("unexpected prompts %q", prompts)
+	}
+
+	p.SetLineReader(nil)
+	if p.Confirm("sure?") {
+		t.Error("expected the input to be read again")
+	}
+}
+
+func TestPrompterPasswordReader(t *testing.T) {
+	p := newTestPrompter("from input\n")
+	p.readPassword = func() ([]byte, error) {
+		t.Fatal("expected the terminal not to be read while the line reader is set")
+		return nil, nil
+	}
+
+	p.SetLineReader(func(prompt string) (string, error) {
+		return "echoed", nil
+	})
+	if pass, err := p.ReadPassphrase("Passphrase: "); err != nil || pass != "echoed" {
+		t.Errorf("expected the line reader's answer, got %q %v", pass, err)
+	}
+
+	var prompt string
+	p.SetPasswordReader(func(pr string) (string, error) {
+		prompt = pr
+		return "secret", nil
+	})
+	if pass, err := p.ReadPassphrase("Passphrase: "); err != nil || pass != "secret" || prompt != "Passphrase: " {
+		t.Errorf("expected the password reader's answer, got %q %v", pass, err)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+)
+
+// A Merkle proof shows a key's value, or its absence, in the trie with the
+// given root. It's serialised as the RLP list of the RLP encoded trie nodes
+// on the path of the key, starting with the root node:
+//
+//	[ rlp(root node), rlp(node), ... ]
+//
+// The root is the sha3 of the root node's encoding and every following node
+// is referenced by the sha3 of its encoding from the node before it. Nodes
+// shorter than 32 bytes are embedded in their parent and don't appear in the
+// list. Keys are looked up by their hex nibbles, exactly like the trie does.
+type Proof struct {
+	Root  []byte
+	Key   []byte
+	Value []byte
+	Nodes [][]byte
+}
+
+var ErrInvalidProof = errors.New("invalid proof")
+
+// Collects the proof for the key from the nodes stored in the database. The
+// trie has to be synced to the database beforehand.
+func NewProof(db ethutil.Database, root interface{}, key []byte) (*Proof, error) {
+	proof := &Proof{Key: key}
+
+	var rootNode *ethutil.Value
+	if isList(ethutil.NewValue(root)) {
+		// Small tries aren't stored by hash, the root node is the root
+		rootNode = ethutil.NewValueFromBytes(ethutil.Encode(root))
+		proof.Nodes = append(proof.Nodes, ethutil.Encode(root))
+	} else {
+		hash := []byte(ethutil.NewValue(root).Str())
+		if len(hash) == 0 {
+			return nil, errors.New("trie is empty")
+		}
+
+		data, err := getNode(db, hash)
+		if err != nil {
+			return nil, err
+		}
+		rootNode = ethutil.NewValueFromBytes(data)
+		proof.Nodes = append(proof.Nodes, data)
+	}
+	proof.Root = ethutil.Sha3Bin(proof.Nodes[0])
+
+	value, err := walkTrie(rootNode, key, func(hash []byte) (*ethutil.Value, error) {
+		data, err := getNode(db, hash)
+		if err != nil {
+			return nil, err
+		}
+		proof.Nodes = append(proof.Nodes, data)
+
+		return ethutil.NewValueFromBytes(data), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	proof.Value = value
+
+	return proof, nil
+}
+
+// Decodes a serialised proof of the key against the root. The value is only
+// known after verifying it.
+func DecodeProof(root, key, data []byte) (*Proof, error) {
+	decoder := ethutil.NewValueFromBytes(data)
+	if !isList(decoder) {
+		return nil, ErrInvalidProof
+	}
+
+	proof := &Proof{Root: root, Key: key}
+	for i := 0; i < decoder.Len(); i++ {
+		proof.Nodes = append(proof.Nodes, []byte(decoder.Get(i).Str()))
+	}
+
+	return proof, nil
+}
+
+func (p *Proof) Encode() []byte {
+	nodes := make([]interface{}, len(p.Nodes))
+	for i, node := range p.Nodes {
+		nodes[i] = node
+	}
+
+	return ethutil.Encode(nodes)
+}
+
+// Checks the proof's nodes against its root and returns the value of the key,
+// nil if the proof shows the key isn't in the trie. Only the proof is needed,
+// no database.
+func (p *Proof) Verify() ([]byte, error) {
+	if len(p.Nodes) == 0 || bytes.Compare(ethutil.Sha3Bin(p.Nodes[0]), p.Root) != 0 {
+		return nil, ErrInvalidProof
+	}
+
+	next := 1
+	value, err := walkTrie(ethutil.NewValueFromBytes(p.Nodes[0]), p.Key, func(hash []byte) (*ethutil.Value, error) {
+		if next == len(p.Nodes) || bytes.Compare(ethutil.Sha3Bin(p.Nodes[next]), hash) != 0 {
+			return nil, ErrInvalidProof
+		}
+		next++
+
+		return ethutil.NewValueFromBytes(p.Nodes[next-1]), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Nodes off the path would make proofs of the same key differ
+	if next != len(p.Nodes) {
+		return nil, ErrInvalidProof
+	}
+
+	return value, nil
+}
+
+// Returns the encoding of the node stored under the hash
+func getNode(db ethutil.Database, hash []byte) ([]byte, error) {
+	data, err := db.Get(hash)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("missing trie node %x", hash)
+	}
+
+	return data, nil
+}
+
+func isList(ref *ethutil.Value) bool {
+	_, ok := ref.Val.([]interface{})
+	return ok
+}
+
+// Follows the key from the root node down to its value. Nodes referenced by
+// hash are looked up with resolve. Returns nil if the key isn't in the trie.
+func walkTrie(node *ethutil.Value, key []byte, resolve func(hash []byte) (*ethutil.Value, error)) ([]byte, error) {
+	path := ethutil.CompactHexDecode(string(key))
+
+	for {
+		var ref *ethutil.Value
+		switch node.Len() {
+		case 2:
+			k := ethutil.CompactDecode(node.Get(0).Str())
+			if len(path) < len(k) || !ethutil.CompareIntSlice(k, path[:len(k)]) {
+				return nil, nil
+			}
+			path = path[len(k):]
+
+			// Leaf keys include the terminator, the whole key matched
+			if len(path) == 0 {
+				return []byte(node.Get(1).Str()), nil
+			}
+			ref = node.Get(1)
+		case 17:
+			if path[0] == 16 {
+				if value := node.Get(16).Str(); len(value) > 0 {
+					return []byte(value), nil
+				}
+
+				return nil, nil
+			}
+			ref = node.Get(path[0])
+			path = path[1:]
+		default:
+			return nil, ErrInvalidProof
+		}
+
+		if isList(ref) {
+			node = ref
+			continue
+		}
+
+		hash := []byte(ref.Str())
+		switch len(hash) {
+		case 0:
+			return nil, nil
+		case 32:
+			var err error
+			if node, err = resolve(hash); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, ErrInvalidProof
+		}
+	}
+}
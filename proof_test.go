@@ -0,0 +1,81 @@
+package main
+
+import (
+	"github.com/ethereum/eth-go/ethdb"
+	"github.com/ethereum/eth-go/ethutil"
+	"strings"
+	"testing"
+)
+
+func newTestProof(t *testing.T, trie *ethutil.Trie, db ethutil.Database, key string) *Proof {
+	proof, err := NewProof(db, trie.Root, []byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeProof(proof.Root, proof.Key, proof.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return decoded
+}
+
+func TestProof(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	trie := ethutil.NewTrie(db, "")
+
+	inputs := map[string]string{"doe": "reindeer", "dog": "puppy", "dogglesworth": "cat"}
+	for key, value := range inputs {
+		trie.Update(key, value)
+	}
+	trie.Sync()
+
+	for key, value := range inputs {
+		verified, err := newTestProof(t, trie, db, key).Verify()
+		if err != nil {
+			t.Fatal(key, err)
+		}
+
+		if string(verified) != value {
+			t.Errorf("expected '%s' for %s, got '%s'", value, key, verified)
+		}
+	}
+
+	if verified, err := newTestProof(t, trie, db, "dogs").Verify(); err != nil || verified != nil {
+		t.Error("expected a proof of absence, got", verified, err)
+	}
+}
+
+func TestProofTampered(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	trie := ethutil.NewTrie(db, "")
+	// Values long enough for every node to be stored by hash
+	trie.Update("doe", strings.Repeat("reindeer", 5))
+	trie.Update("dogglesworth", strings.Repeat("cat", 12))
+	trie.Sync()
+
+	proof := newTestProof(t, trie, db, "dogglesworth")
+	proof.Key = []byte("doe")
+	if _, err := proof.Verify(); err != ErrInvalidProof {
+		t.Error("expected ErrInvalidProof for another key, got", err)
+	}
+
+	proof = newTestProof(t, trie, db, "dogglesworth")
+	proof.Nodes = proof.Nodes[:len(proof.Nodes)-1]
+	if _, err := proof.Verify(); err != ErrInvalidProof {
+		t.Error("expected ErrInvalidProof for a missing node, got", err)
+	}
+
+	proof = newTestProof(t, trie, db, "dogglesworth")
+	proof.Nodes[len(proof.Nodes)-1][0] ^= 1
+	if _, err := proof.Verify(); err != ErrInvalidProof {
+		t.Error("expected ErrInvalidProof for a modified node, got", err)
+	}
+
+	proof = newTestProof(t, trie, db, "dogglesworth")
+	proof.Root = ethutil.Sha3Bin([]byte("root"))
+	if _, err := proof.Verify(); err != ErrInvalidProof {
+		t.Error("expected ErrInvalidProof for another root, got", err)
+	}
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ethereum/eth-go"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Results of the console commands. Their JSON field names are part of the
+// console's JSON output and must not change. Byte strings are hex encoded and
+// amounts are given in Wei as decimal strings.
+
+type TxResult struct {
+	Hash     string `json:"hash"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Value    string `json:"value"`
+	Nonce    uint64 `json:"nonce"`
+	Contract bool   `json:"contract"`
+}
+
+func NewTxResult(tx *ethchain.Transaction) *TxResult {
+	return &TxResult{
+		Hash:     ethutil.Hex(tx.Hash()),
+		From:     ethutil.Hex(tx.Sender()),
+		To:       ethutil.Hex(tx.Recipient),
+		Value:    tx.Value.String(),
+		Nonce:    tx.Nonce,
+		Contract: bytes.Compare(tx.Recipient, ethchain.ContractAddr) == 0,
+	}
+}
+
+func (t *TxResult) String() string {
+	return fmt.Sprintf("tx %s\nfrom  %s\nto    %s\nvalue %s\nnonce %d", t.Hash, t.From, t.To, t.Value, t.Nonce)
+}
+
+type BlockResult struct {
+	Number   uint64      `json:"number"`
+	Hash     string      `json:"hash"`
+	PrevHash string      `json:"prevHash"`
+	Coinbase string      `json:"coinbase"`
+	Time     int64       `json:"time"`
+	Txs      []*TxResult `json:"txs"`
+}
+
+func NewBlockResult(block *ethchain.Block) *BlockResult {
+	b := &BlockResult{
+		Number:   block.BlockInfo().Number,
+		Hash:     ethutil.Hex(block.Hash()),
+		PrevHash: ethutil.Hex(block.PrevHash),
+		Coinbase: ethutil.Hex(block.Coinbase),
+		Time:     block.Time,
+		Txs:      []*TxResult{},
+	}
+
+	for _, tx := range block.Transactions() {
+		b.Txs = append(b.Txs, NewTxResult(tx))
+	}
+
+	return b
+}
+
+func (b *BlockResult) String() string {
+	lines := []string{
+		fmt.Sprintf("block    #%d %s", b.Number, b.Hash),
+		fmt.Sprintf("parent   %s", b.PrevHash),
+		fmt.Sprintf("coinbase %s", b.Coinbase),
+		fmt.Sprintf("time     %v", time.Unix(b.Time, 0)),
+		fmt.Sprintf("txs      %d", len(b.Txs)),
+	}
+	if len(b.Txs) > 0 {
+		lines = append(lines, TxList(b.Txs).String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Lists blocks, one line each
+type BlockList []*BlockResult
+
+func (l BlockList) String() string {
+	var lines []string
+	for _, b := range l {
+		lines = append(lines, fmt.Sprintf("#%-6d %s %v %d txs", b.Number, b.Hash, time.Unix(b.Time, 0).Format("2006-01-02 15:04:05"), len(b.Txs)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Lists transactions, one line each
+type TxList []*TxResult
+
+func (l TxList) String() string {
+	var lines []string
+	for _, tx := range l {
+		to := tx.To
+		if tx.Contract {
+			to = "(new contract)"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s -> %s %s Wei (nonce %d)", tx.Hash, tx.From, to, tx.Value, tx.Nonce))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type AccountResult struct {
+	Index    int    `json:"index"`
+	Label    string `json:"label"`
+	Address  string `json:"address"`
+	Default  bool   `json:"default"`
+	Archived bool   `json:"archived"`
+	Unlocked bool   `json:"unlocked"`
+}
+
+// Returns the accounts of the key ring in order
+func NewAccountResults(keyRing *ethkeys.KeyRing) AccountList {
+	def := keyRing.Default()
+
+	list := AccountList{}
+	for i, acc := range keyRing.Accounts() {
+		list = append(list, &AccountResult{
+			Index:    i,
+			Label:    acc.Label,
+			Address:  acc.Hex(),
+			Default:  acc == def,
+			Archived: acc.Archived,
+			Unlocked: keyRing.IsUnlocked(acc),
+		})
+	}
+
+	return list
+}
+
+// Lists the accounts, the default account is marked with *
+type AccountList []*AccountResult
+
+func (l AccountList) String() string {
+	var lines []string
+	for _, acc := range l {
+		mark := " "
+		if acc.Default {
+			mark = "*"
+		}
+
+		var status string
+		if acc.Archived {
+			status += " (archived)"
+		}
+		if acc.Unlocked {
+			status += " (unlocked)"
+		}
+		lines = append(lines, fmt.Sprintf("%s %d %s %s%s", mark, acc.Index, acc.Address, acc.Label, status))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type AddrResult struct {
+	Address string `json:"address"`
+	Label   string `json:"label,omitempty"`
+	Balance string `json:"balance"`
+	Nonce   uint64 `json:"nonce"`
+
+	amount *big.Int
+}
+
+// Returns the current state of the address
+func NewAddrResult(ethereum *eth.Ethereum, addr []byte, label string) *AddrResult {
+	state := ethereum.BlockManager.GetAddrState(addr)
+
+	return &AddrResult{
+		Address: ethutil.Hex(addr),
+		Label:   label,
+		Balance: state.Account.Amount.String(),
+		Nonce:   state.Nonce,
+		amount:  state.Account.Amount,
+	}
+}
+
+func (a *AddrResult) String() string {
+	return fmt.Sprintf("%s %-12s %v (nonce %d)", a.Address, a.Label, ethutil.CurrencyToString(a.amount), a.Nonce)
+}
+
+// Lists watch-only addresses
+type WatchResults []*AddrResult
+
+func (l WatchResults) String() string {
+	var lines []string
+	for _, a := range l {
+		lines = append(lines, fmt.Sprintf("%s %-12s %v (watch-only)", a.Address, a.Label, ethutil.CurrencyToString(a.amount)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type ProofResult struct {
+	Root  string `json:"root"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Found bool   `json:"found"`
+	Proof string `json:"proof"`
+}
+
+func NewProofResult(proof *Proof) *ProofResult {
+	return &ProofResult{
+		Root:  ethutil.Hex(proof.Root),
+		Key:   ethutil.Hex(proof.Key),
+		Value: ethutil.Hex(proof.Value),
+		Found: proof.Value != nil,
+		Proof: ethutil.Hex(proof.Encode()),
+	}
+}
+
+func (p *ProofResult) String() string {
+	value := p.Value
+	if !p.Found {
+		value = "(not in the trie)"
+	}
+
+	return fmt.Sprintf("root  %s\nkey   %s\nvalue %s\nproof %s", p.Root, p.Key, value, p.Proof)
+}
+
+type TrieEntryResult struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	value []byte
+}
+
+// Lists the key/value pairs of a trie
+type TrieDump []*TrieEntryResult
+
+func NewTrieDump(entries []*TrieEntry) TrieDump {
+	dump := TrieDump{}
+	for _, e := range entries {
+		dump = append(dump, &TrieEntryResult{Key: ethutil.Hex(e.Key), Value: ethutil.Hex(e.Value), value: e.Value})
+	}
+
+	return dump
+}
+
+func (d TrieDump) String() string {
+	var lines []string
+	for _, e := range d {
+		lines = append(lines, fmt.Sprintf("%s: %s", e.Key, formatTrieValue(e.value)))
+	}
+	lines = append(lines, fmt.Sprintf("%d entries", len(d)))
+
+	return strings.Join(lines, "\n")
+}
+
+// From is empty for added keys and To for removed ones
+type TrieChangeResult struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	change *TrieChange
+}
+
+// Lists the keys that differ between two tries
+type TrieDiff []*TrieChangeResult
+
+func NewTrieDiff(changes []*TrieChange) TrieDiff {
+	diff := TrieDiff{}
+	for _, c := range changes {
+		diff = append(diff, &TrieChangeResult{Key: ethutil.Hex(c.Key), From: ethutil.Hex(c.From), To: ethutil.Hex(c.To), change: c})
+	}
+
+	return diff
+}
+
+func (d TrieDiff) String() string {
+	var lines []string
+	for _, c := range d {
+		switch {
+		case c.change.From == nil:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", c.Key, formatTrieValue(c.change.To)))
+		case c.change.To == nil:
+			lines = append(lines, fmt.Sprintf("- %s: %s", c.Key, formatTrieValue(c.change.From)))
+		default:
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", c.Key, formatTrieValue(c.change.From), formatTrieValue(c.change.To)))
+		}
+	}
+	lines = append(lines, fmt.Sprintf("%d changes", len(d)))
+
+	return strings.Join(lines, "\n")
+}
+
+// Shows text values quoted and decodes RLP encoded lists such as the
+// accounts of the state, anything else is shown hex encoded
+func formatTrieValue(value []byte) string {
+	printable := len(value) > 0
+	for _, c := range value {
+		if c < 0x20 || c > 0x7e {
+			printable = false
+			break
+		}
+	}
+
+	switch {
+	case printable:
+		return fmt.Sprintf("%q", value)
+	case len(value) > 0 && value[0] >= 0xc0:
+		return fmt.Sprintf("%v", ethutil.NewValueFromBytes(value))
+	}
+
+	return ethutil.Hex(value)
+}
+
+// A transaction built by the tx command. Balance is the balance of the sender
+// once the transaction and its fee are paid.
+type SendResult struct {
+	*TxResult
+	Fee     string `json:"fee"`
+	Balance string `json:"balance"`
+	DryRun  bool   `json:"dryRun"`
+	// The signed transaction, only given for dry runs
+	Rlp string `json:"rlp,omitempty"`
+	// Address of the contract created by the transaction
+	Address string `json:"address,omitempty"`
+}
+
+func (s *SendResult) String() string {
+	switch {
+	case s.DryRun:
+		return fmt.Sprintf("%v\nrlp   %s", s.TxResult, s.Rlp)
+	case s.Contract:
+		return fmt.Sprintf("contract %s (tx %s)", s.Address, s.Hash)
+	}
+
+	return s.Hash
+}
+
+// The state of a debug session
+type DebugResult struct {
+	PC    int    `json:"pc"`
+	Op    string `json:"op"`
+	Steps int    `json:"steps"`
+	Done  bool   `json:"done"`
+	// Decimal values, top first
+	Stack      []string `json:"stack"`
+	Breakpoint bool     `json:"breakpoint"`
+}
+
+func NewDebugResult(d *Debugger) *DebugResult {
+	result := &DebugResult{PC: d.PC, Op: d.Current(), Steps: d.Steps, Done: d.Done, Stack: []string{}}
+	for n := len(d.Stack) - 1; n >= 0; n-- {
+		result.Stack = append(result.Stack, d.Stack[n].String())
+	}
+
+	for _, offset := range d.Breakpoints() {
+		result.Breakpoint = result.Breakpoint || offset == d.PC
+	}
+
+	return result
+}
+
+func (d *DebugResult) String() string {
+	if d.Done {
+		return fmt.Sprintf("stopped after %d steps at %04d, stack %v", d.Steps, d.PC, d.Stack)
+	}
+
+	var mark string
+	if d.Breakpoint {
+		mark = " (breakpoint)"
+	}
+
+	return fmt.Sprintf("%04d %s%s\nstep  %d\nstack %v", d.PC, d.Op, mark, d.Steps, d.Stack)
+}
+
+// A value of the stack, memory or storage of a debug session. Offsets and
+// values are decimal.
+type DebugValue struct {
+	Offset  string `json:"offset"`
+	Value   string `json:"value"`
+	Written bool   `json:"written,omitempty"`
+}
+
+type DebugValues []*DebugValue
+
+// Lists the values sorted by offset. Written, if given, marks the values the
+// run wrote.
+func NewDebugValues(values map[string]*big.Int, written func(string) bool) DebugValues {
+	var offsets []*big.Int
+	for key := range values {
+		offset, _ := new(big.Int).SetString(key, 10)
+		offsets = append(offsets, offset)
+	}
+	sort.Sort(bigInts(offsets))
+
+	list := DebugValues{}
+	for _, offset := range offsets {
+		v := &DebugValue{Offset: offset.String(), Value: values[offset.String()].String()}
+		v.Written = written != nil && written(v.Offset)
+		list = append(list, v)
+	}
+
+	return list
+}
+
+func (d DebugValues) String() string {
+	var lines []string
+	for _, v := range d {
+		line := fmt.Sprintf("%6s: %s", v.Offset, v.Value)
+		if v.Written {
+			line += " (written)"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, fmt.Sprintf("%d values", len(d)))
+
+	return strings.Join(lines, "\n")
+}
+
+type bigInts []*big.Int
+
+func (b bigInts) Len() int           { return len(b) }
+func (b bigInts) Less(i, j int) bool { return b[i].Cmp(b[j]) < 0 }
+func (b bigInts) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/console"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scripts are files of console commands, one per line. Empty lines and lines
+// starting with # are skipped.
+const scriptComment = "#"
+
+// Default number of seconds the wait commands wait for their condition
+const waitTimeout = 60
+
+var ErrScriptFailed = errors.New("script failed")
+
+// Runs the commands of the script file. Execution stops at the first failing
+// command unless keepGoing is set, in which case ErrScriptFailed is returned
+// at the end if any of them failed.
+func (i *Console) RunScript(path string, keepGoing bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var failed bool
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, scriptComment) {
+			continue
+		}
+
+		i.prompt.Printf("%s:%d> %s\n", path, n, line)
+		result, err := i.commands.Exec(line)
+		if err == ethconsole.ErrQuit {
+			break
+		}
+
+		if err != nil {
+			err = fmt.Errorf("%s:%d: %v", path, n, err)
+			i.commands.Print(nil, err)
+			if !keepGoing {
+				return err
+			}
+
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		} else {
+			i.commands.Print(result, nil)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if failed {
+		return ErrScriptFailed
+	}
+
+	return nil
+}
+
+// Calls cond every second until it returns true or the timeout, given in
+// seconds, passes
+func waitFor(timeout string, cond func() bool) error {
+	seconds := waitTimeout
+	if len(timeout) > 0 {
+		var err error
+		if seconds, err = strconv.Atoi(timeout); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(time.Duration(seconds) * time.Second)
+	for {
+		if cond() {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %ds", seconds)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func optionalArg(args []string, n int) string {
+	if len(args) > n {
+		return args[n]
+	}
+
+	return ""
+}
+
+// Returns the number of the recent block including the transaction, -1 if
+// there is none
+func (i *Console) txBlock(hash []byte) int {
+	for _, block := range i.recentBlocks() {
+		for _, tx := range block.Transactions() {
+			if bytes.Compare(tx.Hash(), hash) == 0 {
+				return int(block.BlockInfo().Number)
+			}
+		}
+	}
+
+	return -1
+}
+
+func (i *Console) registerScriptCommands() {
+	i.register([]*ethconsole.Command{
+		{
+			Name:  "balance",
+			Args:  []ethconsole.Arg{{Name: "ADDR", Complete: i.completeAddrs}},
+			Group: "Scripting",
+			Help:  "Prints the balance of the address",
+			Run: func(args []string) (interface{}, error) {
+				addr, err := hex.DecodeString(args[0])
+				if err != nil {
+					return nil, err
+				}
+				result := NewAddrResult(i.ethereum, addr, "")
+
+				return ethconsole.Text(result, "%s", ethutil.CurrencyToString(result.amount)), nil
+			},
+		},
+		{
+			Name:  "sleep",
+			Args:  []ethconsole.Arg{{Name: "SECONDS"}},
+			Group: "Scripting",
+			Help:  "Pauses for the given number of seconds",
+			Run: func(args []string) (interface{}, error) {
+				seconds, err := strconv.Atoi(args[0])
+				if err != nil {
+					return nil, err
+				}
+				time.Sleep(time.Duration(seconds) * time.Second)
+
+				return nil, nil
+			},
+		},
+		{
+			Name:  "waittx",
+			Args:  []ethconsole.Arg{{Name: "HASH"}, {Name: "SECONDS", Optional: true}},
+			Group: "Scripting",
+			Help:  "Waits until the transaction is included in a block. HASH 'last' is the last transaction sent from the console.",
+			Run: func(args []string) (interface{}, error) {
+				hash := i.lastTx
+				if args[0] != "last" {
+					var err error
+					if hash, err = hex.DecodeString(args[0]); err != nil {
+						return nil, err
+					}
+				}
+
+				if len(hash) == 0 {
+					return nil, errors.New("no transaction sent yet")
+				}
+
+				block := -1
+				err := waitFor(optionalArg(args, 1), func() bool {
+					block = i.txBlock(hash)
+					return block >= 0
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				result := struct {
+					Hash  string `json:"hash"`
+					Block int    `json:"block"`
+				}{ethutil.Hex(hash), block}
+
+				return ethconsole.Text(result, "%x included in block #%d", hash, block), nil
+			},
+		},
+		{
+			Name:  "waitpeers",
+			Args:  []ethconsole.Arg{{Name: "COUNT"}, {Name: "SECONDS", Optional: true}},
+			Group: "Scripting",
+			Help:  "Waits until at least COUNT peers are connected",
+			Run: func(args []string) (interface{}, error) {
+				count, err := strconv.Atoi(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, waitFor(optionalArg(args, 1), func() bool {
+					return i.ethereum.Peers().Len() >= count
+				})
+			},
+		},
+		{
+			Name:  "waitbalance",
+			Args:  []ethconsole.Arg{{Name: "ADDR", Complete: i.completeAddrs}, {Name: "AMOUNT"}, {Name: "SECONDS", Optional: true}},
+			Group: "Scripting",
+			Help:  "Waits until the balance of the address is at least AMOUNT (Wei, or e.g. 2.5ether)",
+			Run: func(args []string) (interface{}, error) {
+				addr, err := hex.DecodeString(args[0])
+				if err != nil {
+					return nil, err
+				}
+				amount, err := ParseAmount(args[1])
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, waitFor(optionalArg(args, 2), func() bool {
+					return i.ethereum.BlockManager.GetAddrState(addr).Account.Amount.Cmp(amount) >= 0
+				})
+			},
+		},
+	})
+}
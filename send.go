@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInsufficientBalance = errors.New("balance doesn't cover the amount and fee")
+
+// Options of the tx command, given after the amount:
+//
+//	nonce=N   sends with nonce N instead of the next nonce of the account
+//	dry       signs the transaction without sending it
+//	yes       sends without asking for confirmation
+//	data ...  the rest of the line is the data, compiled like contract code
+type TxOptions struct {
+	// Sends from the default account unless set
+	From     *ethkeys.Account
+	Nonce    uint64
+	HasNonce bool
+	Data     []string
+	DryRun   bool
+	Yes      bool
+}
+
+func ParseTxOptions(line string) (*TxOptions, error) {
+	opts := &TxOptions{Data: []string{""}}
+
+	words := strings.Fields(line)
+	for n, word := range words {
+		switch {
+		case word == "dry":
+			opts.DryRun = true
+		case word == "yes":
+			opts.Yes = true
+		case strings.HasPrefix(word, "nonce="):
+			nonce, err := strconv.ParseUint(strings.TrimPrefix(word, "nonce="), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("nonce err: %v", err)
+			}
+			opts.Nonce, opts.HasNonce = nonce, true
+		case word == "data":
+			if len(words) == n+1 {
+				return nil, errors.New("data requires at least one item")
+			}
+			opts.Data = ethchain.Compile(words[n+1:])
+
+			return opts, nil
+		default:
+			return nil, fmt.Errorf("unknown option '%s'", word)
+		}
+	}
+
+	return opts, nil
+}
+
+// Builds and signs a transaction, from the default account unless the
+// options name another one. Unless told
+// otherwise a summary is shown and the transaction is only sent once it's
+// confirmed. Dry runs return the signed transaction without sending it.
+func (i *Console) sendTx(to []byte, amount *big.Int, opts *TxOptions) (result *SendResult, err error) {
+	acc := opts.From
+	if acc == nil {
+		if acc = i.keyRing.Default(); acc == nil {
+			return nil, ethkeys.ErrNoKey
+		}
+	}
+
+	// The nonce is reserved before asking, other sessions sending from the
+	// account in the mean time get the ones after it
+	state := i.ethereum.BlockManager.GetAddrState(acc.Address)
+	var nonce uint64
+	switch {
+	case opts.HasNonce:
+		nonce = opts.Nonce
+	case opts.DryRun:
+		nonce = Nonces.Next(acc.Address, state.Nonce)
+	default:
+		nonce = Nonces.Reserve(acc.Address, state.Nonce)
+		defer func() {
+			if err != nil {
+				Nonces.Release(acc.Address, nonce)
+			}
+		}()
+	}
+
+	fee := ethchain.TxFee
+	balance := new(big.Int).Sub(state.Account.Amount, new(big.Int).Add(amount, fee))
+	if balance.Sign() < 0 && !opts.DryRun {
+		return nil, ErrInsufficientBalance
+	}
+
+	if !opts.Yes && !opts.DryRun {
+		i.prompt.Printf("from    '%s' %x\n", acc.Label, acc.Address)
+		if bytes.Compare(to, ethchain.ContractAddr) == 0 {
+			i.prompt.Println("to      (new contract)")
+		} else {
+			i.prompt.Printf("to      %x\n", to)
+		}
+		i.prompt.Printf("amount  %v\n", ethutil.CurrencyToString(amount))
+		i.prompt.Printf("fee     %v (contract calls are charged for execution too)\n", ethutil.CurrencyToString(fee))
+		i.prompt.Printf("nonce   %d\n", nonce)
+		if len(opts.Data) > 1 || len(opts.Data[0]) > 0 {
+			i.prompt.Printf("data    %d items\n", len(opts.Data))
+		}
+		i.prompt.Printf("balance %v -> %v\n", ethutil.CurrencyToString(state.Account.Amount), ethutil.CurrencyToString(balance))
+
+		if !i.prompt.Confirm("Send the transaction?") {
+			return nil, ErrAborted
+		}
+	}
+
+	// Dry runs leave single signature unlocks for the real transaction
+	unlocked := i.keyRing.UnlockedKey
+	if opts.DryRun {
+		unlocked = i.keyRing.PeekKey
+	}
+
+	key, err := unlocked(acc)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := ethchain.NewTransaction(to, amount, opts.Data)
+	tx.Nonce = nonce
+	tx.Sign(key.PrivateKey)
+
+	result = &SendResult{TxResult: NewTxResult(tx), Fee: fee.String(), Balance: balance.String(), DryRun: opts.DryRun}
+	if result.Contract {
+		result.Address = ethutil.Hex(tx.Hash()[12:])
+	}
+	if opts.DryRun {
+		result.Rlp = ethutil.Hex(tx.RlpEncode())
+		return result, nil
+	}
+
+	i.ethereum.TxPool.QueueTransaction(tx)
+	if opts.HasNonce {
+		Nonces.Use(acc.Address, nonce)
+	}
+	i.lastTx = tx.Hash()
+
+	return result, nil
+}
+
+// How long reportContract waits for the contract to be mined
+const contractReportTimeout = 10 * time.Minute
+
+// Tells the session the contract's address once the transaction creating it
+// is included in a block
+func (i *Console) reportContract(hash []byte, address string) {
+	deadline := time.Now().Add(contractReportTimeout)
+	for time.Now().Before(deadline) {
+		if n := i.txBlock(hash); n >= 0 {
+			i.prompt.Printf("\ncontract %s created in block #%d\n", address, n)
+			return
+		}
+		time.Sleep(time.Second)
+	}
+
+	i.prompt.Printf("\ncontract %s not mined after %v\n", address, contractReportTimeout)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
+	"log"
+	"math/big"
+	"time"
+)
+
+// Sweeps requested during key rotation are stored as [ [ from, to ], ... ]
+// and carried out the next time the node runs.
+var sweepKey = []byte("PendingSweeps")
+
+var ErrNothingToSweep = errors.New("balance doesn't cover the transaction fee")
+
+// Moves the whole balance of the account, minus the fee, to the given address.
+// The key is only requested from unlock when there is something to sweep.
+func Sweep(p *Prompter, ethereum *eth.Ethereum, from *ethkeys.Account, to []byte, unlock func(*ethkeys.Account) (*ethutil.Key, error)) (*ethchain.Transaction, error) {
+	state := ethereum.BlockManager.GetAddrState(from.Address)
+	value := new(big.Int).Sub(state.Account.Amount, ethchain.TxFee)
+	if value.Sign() <= 0 {
+		return nil, ErrNothingToSweep
+	}
+
+	p.Printf("Sweeping %v from '%s' to %x\n", ethutil.CurrencyToString(value), from.Label, to)
+	key, err := unlock(from)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := ethchain.NewTransaction(to, value, []string{""})
+	tx.Nonce = state.Nonce
+	tx.Sign(key.PrivateKey)
+	ethereum.TxPool.QueueTransaction(tx)
+
+	return tx, nil
+}
+
+// How long the head of the chain has to stay put before the node is taken to
+// be in sync, and how long pending sweeps wait for that at most
+const (
+	sweepSyncSettle  = 30 * time.Second
+	sweepSyncTimeout = 10 * time.Minute
+)
+
+// Records a sweep from the archived account to its replacement
+func QueueSweep(from, to *ethkeys.Account) {
+	saveSweeps(append([]*PendingSweep{{from: from.Address, to: to.Address}}, loadSweeps()...))
+	fmt.Println("The old balance will be swept once the node is in sync")
+}
+
+// A queued sweep. The account is set once it's unlocked for the sweep.
+type PendingSweep struct {
+	from, to []byte
+	acc      *ethkeys.Account
+}
+
+func loadSweeps() []*PendingSweep {
+	data, _ := ethutil.Config.Db.Get(sweepKey)
+	if len(data) == 0 {
+		return nil
+	}
+
+	var sweeps []*PendingSweep
+	decoder := ethutil.NewValueFromBytes(data)
+	for i := 0; i < decoder.Len(); i++ {
+		sweeps = append(sweeps, &PendingSweep{from: decoder.Get(i).Get(0).Bytes(), to: decoder.Get(i).Get(1).Bytes()})
+	}
+
+	return sweeps
+}
+
+func saveSweeps(sweeps []*PendingSweep) {
+	entries := []interface{}{}
+	for _, s := range sweeps {
+		entries = append(entries, []interface{}{s.from, s.to})
+	}
+
+	ethutil.Config.Db.Put(sweepKey, ethutil.Encode(entries))
+}
+
+// Unlocks the accounts of the queued sweeps for a single signature. This
+// happens on start up, before the consoles read the terminal. Sweeps of
+// accounts which are gone from the key ring are dropped, those which can't be
+// unlocked stay queued for the next start.
+func UnlockSweeps(p *Prompter, keyRing *ethkeys.KeyRing) []*PendingSweep {
+	queued := loadSweeps()
+
+	var sweeps []*PendingSweep
+	for _, s := range queued {
+		acc, err := keyRing.Find(ethutil.Hex(s.from))
+		if err != nil {
+			log.Println("sweep err:", err)
+			continue
+		}
+		sweeps = append(sweeps, s)
+
+		pass, err := p.ReadPassphrase(fmt.Sprintf("Passphrase for '%s' to sweep its balance: ", acc.Label))
+		if err == nil {
+			err = keyRing.Unlock(acc, pass, 0)
+		}
+		if err != nil {
+			log.Println("sweep err:", err)
+			continue
+		}
+		s.acc = acc
+	}
+
+	if len(sweeps) < len(queued) {
+		saveSweeps(sweeps)
+	}
+
+	return sweeps
+}
+
+// Waits for the node to connect and catch up with the chain. Balances read
+// before that may be missing the funds to sweep. Returns false if the node
+// isn't in sync before the timeout, e.g. because the GUI was never told to
+// connect.
+func waitForSync(ethereum *eth.Ethereum, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for ethereum.Peers().Len() == 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Second)
+	}
+
+	var head []byte
+	settled := time.Now()
+	for time.Since(settled) < sweepSyncSettle {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Second)
+		if current := ethereum.BlockManager.BlockChain().CurrentBlock; current != nil && string(current.Hash()) != string(head) {
+			head, settled = current.Hash(), time.Now()
+		}
+	}
+
+	return true
+}
+
+// Carries out the sweeps unlocked by UnlockSweeps once the node is in sync.
+// They're signed with the unlocked keys only, an account locked in the mean
+// time isn't swept. Sweeps which aren't sent stay queued, accounts without
+// funds yet may still receive them.
+func SweepPending(ethereum *eth.Ethereum, keyRing *ethkeys.KeyRing, sweeps []*PendingSweep) {
+	if len(sweeps) == 0 {
+		return
+	}
+
+	// The keys aren't left unlocked once the sweeps are done
+	defer func() {
+		for _, s := range sweeps {
+			if s.acc != nil {
+				keyRing.Lock(s.acc)
+			}
+		}
+	}()
+
+	if !waitForSync(ethereum, sweepSyncTimeout) {
+		log.Printf("sweep err: node not in sync after %v, sweeps stay queued\n", sweepSyncTimeout)
+		return
+	}
+
+	var remaining []*PendingSweep
+	for _, s := range sweeps {
+		if s.acc == nil {
+			remaining = append(remaining, s)
+			continue
+		}
+
+		tx, err := Sweep(Prompt, ethereum, s.acc, s.to, keyRing.UnlockedKey)
+		switch err {
+		case nil:
+			log.Printf("Sweep %x => %x queued (%x)\n", s.from, s.to, tx.Hash())
+		case ErrNothingToSweep:
+			log.Printf("Nothing to sweep from %x yet, kept for the next start\n", s.from)
+			remaining = append(remaining, s)
+		default:
+			log.Println("sweep err:", err)
+			remaining = append(remaining, s)
+		}
+	}
+
+	saveSweeps(remaining)
+}
@@ -23,13 +23,22 @@ ApplicationWindow {
 		anchors.bottomMargin: 5
 		anchors.horizontalCenter: parent.horizontalCenter
 	}
+	TextField {
+		id: passphraseField
+		anchors.verticalCenter: parent.verticalCenter
+		anchors.left: textField.right
+		anchors.leftMargin: 5
+		placeholderText: "Passphrase"
+		echoMode: TextInput.Password
+	}
 	Button {
 		anchors.top: textField.bottom
 		anchors.horizontalCenter: parent.horizontalCenter
 		anchors.topMargin: 5
 		text: "Place bet"
 		onClicked: {
-			txHash.text = eth.createTx("e6716f9544a56c530d868e4bfbacb172315bdead", textField.text)
+			var err = eth.unlock("", passphraseField.text, 0)
+			txHash.text = err.length > 0 ? err : eth.createTx("", "e6716f9544a56c530d868e4bfbacb172315bdead", textField.text, "")
 		}
 	}
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ethereum/eth-go/ethutil"
+)
+
+// A key/value pair stored in a trie
+type TrieEntry struct {
+	Key   []byte
+	Value []byte
+}
+
+// A key that differs between two tries. From is nil for added keys, To is nil
+// for removed keys.
+type TrieChange struct {
+	Key  []byte
+	From []byte
+	To   []byte
+}
+
+// Returns the key/value pairs of the trie with the given root, ordered by
+// key. The nodes are read from the database, the trie has to be synced to it.
+func TrieEntries(db ethutil.Database, root interface{}) ([]*TrieEntry, error) {
+	var entries []*TrieEntry
+	err := walkEntries(db, ethutil.NewValue(root), nil, func(nibbles []int, value []byte) {
+		entries = append(entries, &TrieEntry{Key: nibblesToBytes(nibbles), Value: value})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Lists the keys added, removed and changed from one trie to the other,
+// ordered by key
+func DiffTries(fromDb ethutil.Database, from interface{}, toDb ethutil.Database, to interface{}) ([]*TrieChange, error) {
+	before, err := TrieEntries(fromDb, from)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := TrieEntries(toDb, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*TrieChange
+	for len(before) > 0 || len(after) > 0 {
+		var cmp int
+		switch {
+		case len(before) == 0:
+			cmp = 1
+		case len(after) == 0:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(before[0].Key, after[0].Key)
+		}
+
+		switch {
+		case cmp < 0:
+			changes = append(changes, &TrieChange{Key: before[0].Key, From: before[0].Value})
+			before = before[1:]
+		case cmp > 0:
+			changes = append(changes, &TrieChange{Key: after[0].Key, To: after[0].Value})
+			after = after[1:]
+		default:
+			if bytes.Compare(before[0].Value, after[0].Value) != 0 {
+				changes = append(changes, &TrieChange{Key: before[0].Key, From: before[0].Value, To: after[0].Value})
+			}
+			before, after = before[1:], after[1:]
+		}
+	}
+
+	return changes, nil
+}
+
+// Calls fn with the nibbles of every key below the referenced node, in order
+func walkEntries(db ethutil.Database, ref *ethutil.Value, path []int, fn func(nibbles []int, value []byte)) error {
+	node := ref
+	if !isList(ref) {
+		hash := []byte(ref.Str())
+		if len(hash) == 0 {
+			return nil
+		}
+
+		data, err := getNode(db, hash)
+		if err != nil {
+			return err
+		}
+		node = ethutil.NewValueFromBytes(data)
+	}
+
+	switch node.Len() {
+	case 2:
+		k := ethutil.CompactDecode(node.Get(0).Str())
+		// Leaf keys end with the terminator
+		if len(k) > 0 && k[len(k)-1] == 16 {
+			fn(joinNibbles(path, k[:len(k)-1]...), []byte(node.Get(1).Str()))
+			return nil
+		}
+
+		return walkEntries(db, node.Get(1), joinNibbles(path, k...), fn)
+	case 17:
+		if value := node.Get(16).Str(); len(value) > 0 {
+			fn(path, []byte(value))
+		}
+
+		for n := 0; n < 16; n++ {
+			if err := walkEntries(db, node.Get(n), joinNibbles(path, n), fn); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("invalid trie node %v", node)
+}
+
+func joinNibbles(path []int, nibbles ...int) []int {
+	joined := make([]int, 0, len(path)+len(nibbles))
+
+	return append(append(joined, path...), nibbles...)
+}
+
+func nibblesToBytes(nibbles []int) []byte {
+	key := make([]byte, len(nibbles)/2)
+	for i := range key {
+		key[i] = byte(nibbles[2*i]<<4 | nibbles[2*i+1])
+	}
+
+	return key
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/ethereum/eth-go/ethdb"
+	"github.com/ethereum/eth-go/ethutil"
+	"testing"
+)
+
+func TestTrieEntries(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	trie := ethutil.NewTrie(db, "")
+	trie.Update("dogglesworth", "cat")
+	trie.Update("doe", "reindeer")
+	trie.Update("dog", "puppy")
+	trie.Sync()
+
+	entries, err := TrieEntries(db, trie.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"doe", "reindeer", "dog", "puppy", "dogglesworth", "cat"}
+	if len(entries) != len(expected)/2 {
+		t.Fatalf("expected %d entries, got %d", len(expected)/2, len(entries))
+	}
+
+	for i, e := range entries {
+		if string(e.Key) != expected[2*i] || string(e.Value) != expected[2*i+1] {
+			t.Errorf("expected %s: %s, got %s: %s", expected[2*i], expected[2*i+1], e.Key, e.Value)
+		}
+	}
+}
+
+func TestDiffTries(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	trie := ethutil.NewTrie(db, "")
+	trie.Update("doe", "reindeer")
+	trie.Update("dog", "puppy")
+	trie.Sync()
+	from := trie.Root
+
+	trie.Update("dog", "hound")
+	trie.Update("doe", "")
+	trie.Update("horse", "stallion")
+	trie.Sync()
+
+	changes, err := DiffTries(db, from, db, trie.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d", len(changes))
+	}
+
+	if c := changes[0]; string(c.Key) != "doe" || c.To != nil {
+		t.Error("expected doe to be removed, got", c)
+	}
+
+	if c := changes[1]; string(c.Key) != "dog" || string(c.From) != "puppy" || string(c.To) != "hound" {
+		t.Error("expected dog to change, got", c)
+	}
+
+	if c := changes[2]; string(c.Key) != "horse" || c.From != nil {
+		t.Error("expected horse to be added, got", c)
+	}
+}
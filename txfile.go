@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/eth-go"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"strings"
+)
+
+// Transactions signed offline are written in one of three formats: the raw
+// RLP encoding ("rlp"), the hex encoded RLP ("hex") or a JSON document
+// ("json") carrying the hex encoded RLP along with its decoded fields for
+// inspection. Only the RLP is used when the file is loaded, the other fields
+// are checked against it.
+const TxFileVersion = 1
+
+var (
+	ErrInvalidSignature = errors.New("transaction signature is invalid")
+	ErrMalformedTx      = errors.New("malformed transaction RLP")
+)
+
+type TxFile struct {
+	Version int      `json:"version"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Value   string   `json:"value"`
+	Nonce   uint64   `json:"nonce"`
+	Data    []string `json:"data,omitempty"`
+	Hash    string   `json:"hash"`
+	Rlp     string   `json:"rlp"`
+}
+
+// Builds and signs a transaction with an explicit nonce, without touching the
+// network, and writes it to the given path
+func SignTxFile(acc *ethkeys.Account, recipient, value string, nonce uint64, dataPath, path, format string) error {
+	hash := ethchain.ContractAddr
+	if len(recipient) > 0 {
+		var err error
+		hash, err = hex.DecodeString(recipient)
+		if err != nil {
+			return fmt.Errorf("recipient err: %v", err)
+		}
+	}
+
+	amount, err := ParseAmount(value)
+	if err != nil {
+		return err
+	}
+
+	data := []string{""}
+	if len(dataPath) > 0 {
+		code, err := ioutil.ReadFile(dataPath)
+		if err != nil {
+			return err
+		}
+		data = ethchain.Compile(strings.Split(string(code), "\n"))
+	}
+
+	key, err := UnlockAccount(acc)
+	if err != nil {
+		return err
+	}
+
+	tx := ethchain.NewTransaction(hash, amount, data)
+	tx.Nonce = nonce
+	tx.Sign(key.PrivateKey)
+
+	if err := WriteTxFile(tx, path, format); err != nil {
+		return err
+	}
+	fmt.Printf("signed tx %x (nonce %d) written to %s\n", tx.Hash(), tx.Nonce, path)
+
+	return nil
+}
+
+func WriteTxFile(tx *ethchain.Transaction, path, format string) error {
+	var out []byte
+	switch format {
+	case "rlp":
+		out = tx.RlpEncode()
+	case "hex":
+		out = []byte(ethutil.Hex(tx.RlpEncode()) + "\n")
+	case "json":
+		var err error
+		out, err = json.MarshalIndent(&TxFile{
+			Version: TxFileVersion,
+			From:    ethutil.Hex(tx.Sender()),
+			To:      ethutil.Hex(tx.Recipient),
+			Value:   tx.Value.String(),
+			Nonce:   tx.Nonce,
+			Data:    tx.Data,
+			Hash:    ethutil.Hex(tx.Hash()),
+			Rlp:     ethutil.Hex(tx.RlpEncode()),
+		}, "", "\t")
+		if err != nil {
+			return err
+		}
+		out = append(out, '\n')
+	default:
+		return fmt.Errorf("unknown transaction format '%s' (hex, rlp or json)", format)
+	}
+
+	return ioutil.WriteFile(path, out, 0644)
+}
+
+// Decodes the RLP of a transaction. The decoder panics on input which isn't
+// a transaction, e.g. a truncated file, which is returned as an error instead
+// of taking the node down.
+func DecodeTx(data []byte) (tx *ethchain.Transaction, err error) {
+	defer func() {
+		if recover() != nil {
+			tx, err = nil, ErrMalformedTx
+		}
+	}()
+
+	if len(data) == 0 {
+		return nil, ErrMalformedTx
+	}
+
+	return ethchain.NewTransactionFromBytes(data), nil
+}
+
+// Reads a transaction file in any of the supported formats. The sender is
+// only known for JSON files, it's nil for the others.
+func ReadTxFile(path string) (tx *ethchain.Transaction, from []byte, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		file := &TxFile{}
+		if err := json.Unmarshal(trimmed, file); err != nil {
+			return nil, nil, err
+		}
+
+		if file.Version != TxFileVersion {
+			return nil, nil, fmt.Errorf("unsupported transaction file version %d", file.Version)
+		}
+
+		if from, err = hex.DecodeString(file.From); err != nil || len(from) != 20 {
+			return nil, nil, fmt.Errorf("invalid sender '%s'", file.From)
+		}
+
+		rlp, err := hex.DecodeString(file.Rlp)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if tx, err = DecodeTx(rlp); err != nil {
+			return nil, nil, err
+		}
+
+		if file.Hash != ethutil.Hex(tx.Hash()) || file.Nonce != tx.Nonce || file.Value != tx.Value.String() {
+			return nil, nil, errors.New("transaction file fields don't match its RLP")
+		}
+
+		return tx, from, nil
+	}
+
+	if rlp, err := hex.DecodeString(string(trimmed)); err == nil {
+		tx, err = DecodeTx(rlp)
+		return tx, nil, err
+	}
+
+	tx, err = DecodeTx(data)
+	return tx, nil, err
+}
+
+// Recovers the signer of the transaction, failing if the signature is invalid
+func ValidateTx(tx *ethchain.Transaction) (sender []byte, err error) {
+	// Recovering a key from a malformed signature panics
+	defer func() {
+		if recover() != nil {
+			sender, err = nil, ErrInvalidSignature
+		}
+	}()
+
+	pub := tx.PublicKey()
+	if len(pub) != 65 || pub[0] != 4 {
+		return nil, ErrInvalidSignature
+	}
+
+	return ethutil.Sha3Bin(pub[1:])[12:], nil
+}
+
+// Loads a signed transaction, validates it and queues it for broadcasting.
+// The signer has to match the sender of JSON files, and its account has to
+// cover the value with a nonce which isn't used yet.
+func SendTxFile(ethereum *eth.Ethereum, path string) (*ethchain.Transaction, error) {
+	tx, from, err := ReadTxFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := ValidateTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if from != nil && !bytes.Equal(from, sender) {
+		return nil, fmt.Errorf("transaction is signed by %x, not by its sender %x", sender, from)
+	}
+
+	state := ethereum.BlockManager.GetAddrState(sender)
+	if tx.Nonce < state.Nonce {
+		return nil, fmt.Errorf("tx nonce %d is below the account nonce %d", tx.Nonce, state.Nonce)
+	}
+
+	if next := Nonces.Next(sender, state.Nonce); tx.Nonce > next {
+		log.Printf("tx nonce %d is above the next nonce %d, it waits for the ones before it\n", tx.Nonce, next)
+	}
+
+	if tx.Value.Sign() < 0 || new(big.Int).Add(tx.Value, ethchain.TxFee).Cmp(state.Account.Amount) > 0 {
+		return nil, ErrInsufficientBalance
+	}
+
+	ethereum.TxPool.QueueTransaction(tx)
+	Nonces.Use(sender, tx.Nonce)
+
+	return tx, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/ethereum/eth-go/ethchain"
 	"github.com/ethereum/eth-go/ethdb"
 	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
 	"github.com/niemeyer/qml"
 	"bitbucket.org/kardianos/osext"
     "path/filepath"
@@ -22,7 +23,7 @@ type Block struct {
 }
 
 type Tx struct {
-	Value, Hash, Address string
+	Value, Hash, Address, Account string
 }
 
 func NewTxFromTransaction(tx *ethchain.Transaction) *Tx {
@@ -32,6 +33,23 @@ func NewTxFromTransaction(tx *ethchain.Transaction) *Tx {
 	return &Tx{Hash: hash, Value: ethutil.CurrencyToString(tx.Value), Address: sender}
 }
 
+type Account struct {
+	Label, Address string
+}
+
+func NewAccountFromAccount(acc *ethkeys.Account) *Account {
+	return &Account{Label: acc.Label, Address: acc.Hex()}
+}
+
+// A watch-only address and its balance
+type Watch struct {
+	Label, Address, Value string
+}
+
+func NewWatchFromWatched(w *ethkeys.Watched, amount *big.Int) *Watch {
+	return &Watch{Label: w.Label, Address: w.Hex(), Value: ethutil.CurrencyToString(amount)}
+}
+
 // Creates a new QML Block from a chain block
 func NewBlockFromBlock(block *ethchain.Block) *Block {
 	info := block.BlockInfo()
@@ -54,23 +72,24 @@ type Gui struct {
 
 	txDb *ethdb.LDBDatabase
 
-	addr []byte
+	keyRing   *ethkeys.KeyRing
+	watchList *ethkeys.WatchList
+	addr      []byte
 }
 
 // Create GUI, but doesn't start it
-func New(ethereum *eth.Ethereum) *Gui {
-	lib := &EthLib{blockManager: ethereum.BlockManager, blockChain: ethereum.BlockManager.BlockChain(), txPool: ethereum.TxPool}
+func New(ethereum *eth.Ethereum, keyRing *ethkeys.KeyRing, watchList *ethkeys.WatchList) *Gui {
+	lib := &EthLib{blockManager: ethereum.BlockManager, blockChain: ethereum.BlockManager.BlockChain(), txPool: ethereum.TxPool, keyRing: keyRing, watchList: watchList}
 	db, err := ethdb.NewLDBDatabase("tx_database")
 	if err != nil {
 		panic(err)
 	}
 
-	key := ethutil.Config.Db.GetKeys()[0]
-	addr := key.Address()
-
-	ethereum.BlockManager.WatchAddr(addr)
+	for _, acc := range keyRing.Accounts() {
+		ethereum.BlockManager.WatchAddr(acc.Address)
+	}
 
-	return &Gui{eth: ethereum, lib: lib, txDb: db, addr: addr}
+	return &Gui{eth: ethereum, lib: lib, txDb: db, keyRing: keyRing, watchList: watchList, addr: keyRing.Default().Address}
 }
 
 func (ui *Gui) Start() {
@@ -80,7 +99,11 @@ func (ui *Gui) Start() {
 	qml.RegisterTypes("Ethereum", 1, 0, []qml.TypeSpec{{
 		Init: func(p *Block, obj qml.Object) { p.Number = 0; p.Hash = "" },
 	}, {
-		Init: func(p *Tx, obj qml.Object) { p.Value = ""; p.Hash = ""; p.Address = "" },
+		Init: func(p *Tx, obj qml.Object) { p.Value = ""; p.Hash = ""; p.Address = ""; p.Account = "" },
+	}, {
+		Init: func(p *Account, obj qml.Object) { p.Label = ""; p.Address = "" },
+	}, {
+		Init: func(p *Watch, obj qml.Object) { p.Label = ""; p.Address = ""; p.Value = "" },
 	}})
 
 	ethutil.Config.Log.Infoln("[GUI] Starting GUI")
@@ -112,6 +135,9 @@ func (ui *Gui) Start() {
 	// Add the ui as a log system so we can log directly to the UGI
 	ethutil.Config.Log.AddLogSystem(ui)
 
+	ui.setAccounts()
+	ui.setWatchList()
+
 	// Loads previous blocks
 	go ui.setInitialBlockChain()
 	go ui.readPreviousTransactions()
@@ -132,12 +158,57 @@ func (ui *Gui) setInitialBlockChain() {
 
 }
 
+// Fills the account selection with the key ring's accounts, default first
+func (ui *Gui) setAccounts() {
+	def := ui.keyRing.Default()
+	ui.win.Root().Call("addAccount", NewAccountFromAccount(def))
+	for _, acc := range ui.keyRing.Accounts() {
+		if acc != def {
+			ui.win.Root().Call("addAccount", NewAccountFromAccount(acc))
+		}
+	}
+}
+
+func (ui *Gui) setWatchList() {
+	for _, w := range ui.watchList.Entries() {
+		ui.win.Root().Call("addWatch", NewWatchFromWatched(w, ui.eth.BlockManager.GetAddrState(w.Address).Account.Amount))
+	}
+}
+
+// Returns the watch-only address the transaction is sent from or to, if any
+func (ui *Gui) watchedBy(tx *ethchain.Transaction) *ethkeys.Watched {
+	if w := ui.watchList.Get(tx.Sender()); w != nil {
+		return w
+	}
+
+	return ui.watchList.Get(tx.Recipient)
+}
+
+// Creates the QML transaction, labeled with the account or watch-only address
+// it belongs to
+func (ui *Gui) newTx(tx *ethchain.Transaction) *Tx {
+	t := NewTxFromTransaction(tx)
+	for _, addr := range [][]byte{tx.Sender(), tx.Recipient} {
+		if acc, err := ui.keyRing.Find(ethutil.Hex(addr)); err == nil {
+			t.Account = acc.Label
+
+			return t
+		}
+	}
+
+	if w := ui.watchedBy(tx); w != nil {
+		t.Account = w.Label + " (watch-only)"
+	}
+
+	return t
+}
+
 func (ui *Gui) readPreviousTransactions() {
 	it := ui.txDb.Db().NewIterator(nil, nil)
 	for it.Next() {
 		tx := ethchain.NewTransactionFromBytes(it.Value())
 
-		ui.win.Root().Call("addTx", NewTxFromTransaction(tx))
+		ui.win.Root().Call("addTx", ui.newTx(tx))
 	}
 	it.Release()
 }
@@ -159,15 +230,28 @@ func (ui *Gui) update() {
 		case txMsg := <-txChan:
 			tx := txMsg.Tx
 
+			// Watch-only addresses only show up in the history and have
+			// their balance updated, they don't count towards the wallet
+			if w := ui.watchedBy(tx); w != nil {
+				if txMsg.Type == ethchain.TxPre {
+					if bytes.Compare(tx.Sender(), ui.addr) != 0 && bytes.Compare(tx.Recipient, ui.addr) != 0 {
+						ui.win.Root().Call("addTx", ui.newTx(tx))
+						ui.txDb.Put(tx.Hash(), tx.RlpEncode())
+					}
+				} else {
+					ui.win.Root().Call("setWatchValue", w.Hex(), ethutil.CurrencyToString(ui.eth.BlockManager.GetAddrState(w.Address).Account.Amount))
+				}
+			}
+
 			if txMsg.Type == ethchain.TxPre {
 				if bytes.Compare(tx.Sender(), ui.addr) == 0 {
-					ui.win.Root().Call("addTx", NewTxFromTransaction(tx))
+					ui.win.Root().Call("addTx", ui.newTx(tx))
 					ui.txDb.Put(tx.Hash(), tx.RlpEncode())
 
 					ui.eth.BlockManager.GetAddrState(ui.addr).Nonce += 1
 					unconfirmedFunds.Sub(unconfirmedFunds, tx.Value)
 				} else if bytes.Compare(tx.Recipient, ui.addr) == 0 {
-					ui.win.Root().Call("addTx", NewTxFromTransaction(tx))
+					ui.win.Root().Call("addTx", ui.newTx(tx))
 					ui.txDb.Put(tx.Hash(), tx.RlpEncode())
 
 					unconfirmedFunds.Add(unconfirmedFunds, tx.Value)
@@ -5,16 +5,72 @@ import (
 	"fmt"
 	"github.com/ethereum/eth-go/ethchain"
 	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
 	"strings"
+	"time"
 )
 
 type EthLib struct {
 	blockManager *ethchain.BlockManager
 	blockChain   *ethchain.BlockChain
 	txPool       *ethchain.TxPool
+	keyRing      *ethkeys.KeyRing
+	watchList    *ethkeys.WatchList
 }
 
-func (lib *EthLib) CreateTx(receiver, a, data string) string {
+// Accounts may be given by label, address or index; an empty account means
+// the default account.
+func (lib *EthLib) account(account string) (*ethkeys.Account, error) {
+	if len(account) == 0 {
+		if acc := lib.keyRing.Default(); acc != nil {
+			return acc, nil
+		}
+
+		return nil, ethkeys.ErrNoKey
+	}
+
+	return lib.keyRing.Find(account)
+}
+
+// Unlocks the account for the given number of seconds, or for a single
+// signature if seconds is zero. Returns an error message or an empty string.
+func (lib *EthLib) Unlock(account, passphrase string, seconds int) string {
+	acc, err := lib.account(account)
+	if err != nil {
+		return err.Error()
+	}
+
+	if seconds < 0 {
+		return fmt.Sprintf("invalid duration %d", seconds)
+	}
+
+	if err := lib.keyRing.Unlock(acc, passphrase, time.Duration(seconds)*time.Second); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+func (lib *EthLib) Lock(account string) {
+	if acc, err := lib.account(account); err == nil {
+		lib.keyRing.Lock(acc)
+	}
+}
+
+func (lib *EthLib) IsUnlocked(account string) bool {
+	acc, err := lib.account(account)
+
+	return err == nil && lib.keyRing.IsUnlocked(acc)
+}
+
+// Creates, signs and queues a transaction. The sending account has to be
+// unlocked, the error is returned in place of the hash otherwise.
+func (lib *EthLib) CreateTx(account, receiver, a, data string) string {
+	acc, err := lib.account(account)
+	if err != nil {
+		return err.Error()
+	}
+
 	var hash []byte
 	if len(receiver) == 0 {
 		hash = ethchain.ContractAddr
@@ -26,15 +82,17 @@ func (lib *EthLib) CreateTx(receiver, a, data string) string {
 		}
 	}
 
-	k, _ := ethutil.Config.Db.Get([]byte("KeyRing"))
-	keyRing := ethutil.NewValueFromBytes(k)
+	key, err := lib.keyRing.UnlockedKey(acc)
+	if err != nil {
+		return err.Error()
+	}
 
 	amount := ethutil.Big(a)
 	code := ethchain.Compile(strings.Split(data, "\n"))
 	tx := ethchain.NewTransaction(hash, amount, code)
-	tx.Nonce = lib.blockManager.GetAddrState(keyRing.Get(1).Bytes()).Nonce
+	tx.Nonce = lib.blockManager.GetAddrState(key.Address()).Nonce
 
-	tx.Sign(keyRing.Get(0).Bytes())
+	tx.Sign(key.PrivateKey)
 
 	lib.txPool.QueueTransaction(tx)
 
@@ -47,6 +105,24 @@ func (lib *EthLib) CreateTx(receiver, a, data string) string {
 	return ethutil.Hex(tx.Hash())
 }
 
+// Adds a watch-only address. Returns an error message or an empty string.
+func (lib *EthLib) WatchAddr(address, label string) string {
+	if err := WatchAddress(lib.blockManager, lib.keyRing, lib.watchList, address, label); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+func (lib *EthLib) GetBalance(address string) string {
+	addr, err := hex.DecodeString(address)
+	if err != nil {
+		return ""
+	}
+
+	return ethutil.CurrencyToString(lib.blockManager.GetAddrState(addr).Account.Amount)
+}
+
 func (lib *EthLib) GetBlock(hexHash string) *Block {
 	hash, err := hex.DecodeString(hexHash)
 	if err != nil {
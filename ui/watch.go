@@ -0,0 +1,40 @@
+package ethui
+
+import (
+	"encoding/hex"
+	"errors"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/go-ethereum/keys"
+)
+
+// Adds the hex address to the watch list, unless it's an account of the key
+// ring, and starts tracking its state
+func WatchAddress(bm *ethchain.BlockManager, keyRing *ethkeys.KeyRing, watchList *ethkeys.WatchList, address, label string) error {
+	addr, err := hex.DecodeString(address)
+	if err != nil {
+		return err
+	}
+
+	if _, err := keyRing.Find(address); err == nil {
+		return errors.New("address is an account of the key ring")
+	}
+
+	if _, err := watchList.Add(label, addr); err != nil {
+		return err
+	}
+	bm.WatchAddr(addr)
+
+	return nil
+}
+
+// Removes the hex address from the watch list. The block manager can't stop
+// tracking an address, its state is tracked until the node restarts and only
+// the watch list is watched again.
+func UnwatchAddress(watchList *ethkeys.WatchList, address string) error {
+	addr, err := hex.DecodeString(address)
+	if err != nil {
+		return err
+	}
+
+	return watchList.Remove(addr)
+}
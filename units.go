@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Units accepted after amounts, amounts without a unit are given in Wei
+var units = map[string]*big.Int{
+	"wei":    big.NewInt(1),
+	"szabo":  new(big.Int).Exp(big.NewInt(10), big.NewInt(12), nil),
+	"finney": new(big.Int).Exp(big.NewInt(10), big.NewInt(15), nil),
+	"ether":  new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
+}
+
+// Parses amounts such as "100", "2.5ether" or "10 finney" to Wei
+func ParseAmount(amount string) (*big.Int, error) {
+	amount = strings.ToLower(strings.TrimSpace(amount))
+	number := strings.TrimRight(amount, "abcdefghijklmnopqrstuvwxyz")
+	unit := strings.TrimSpace(amount[len(number):])
+	number = strings.TrimSpace(number)
+
+	multiplier := units["wei"]
+	if len(unit) > 0 {
+		var ok bool
+		if multiplier, ok = units[unit]; !ok {
+			return nil, fmt.Errorf("unknown unit '%s' (wei, szabo, finney or ether)", unit)
+		}
+	}
+
+	// Only plain decimals, big.Rat would take fractions and exponents too
+	value, ok := new(big.Rat).SetString(number)
+	if !ok || !isDecimal(number) {
+		return nil, fmt.Errorf("invalid amount '%s'", amount)
+	}
+
+	value.Mul(value, new(big.Rat).SetInt(multiplier))
+	if !value.IsInt() {
+		return nil, fmt.Errorf("amount '%s' has fractions of a Wei", amount)
+	}
+
+	return value.Num(), nil
+}
+
+// Splits a command line into the amount and what follows it. The unit may
+// follow the amount as a word of its own, as in "10 finney".
+func SplitAmount(amount, rest string) (string, string) {
+	words := strings.Fields(rest)
+	if len(words) > 0 && units[strings.ToLower(words[0])] != nil {
+		return amount + words[0], strings.Join(words[1:], " ")
+	}
+
+	return amount, rest
+}
+
+func isDecimal(number string) bool {
+	var digits, dots int
+	for _, c := range number {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.':
+			dots++
+		default:
+			return false
+		}
+	}
+
+	return digits > 0 && dots <= 1
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	amounts := map[string]string{
+		"100":          "100",
+		"0":            "0",
+		"100wei":       "100",
+		"2.5ether":     "2500000000000000000",
+		"10 finney":    "10000000000000000",
+		"1.5Szabo":     "1500000000000",
+		" 0.001ether ": "1000000000000000",
+	}
+
+	for amount, expected := range amounts {
+		value, err := ParseAmount(amount)
+		if err != nil {
+			t.Errorf("%s: %v", amount, err)
+		} else if value.String() != expected {
+			t.Errorf("%s: expected %s, got %v", amount, expected, value)
+		}
+	}
+
+	for _, amount := range []string{"", "ether", "1.5", "-1", "1/2ether", "1e18", "10 gwei", "0x10"} {
+		if _, err := ParseAmount(amount); err == nil {
+			t.Errorf("%s: expected an error", amount)
+		}
+	}
+}
+
+func TestSplitAmount(t *testing.T) {
+	tests := []struct{ amount, rest, expAmount, expRest string }{
+		{"10", "finney yes", "10finney", "yes"},
+		{"10", "Ether", "10Ether", ""},
+		{"2.5ether", "dry", "2.5ether", "dry"},
+		{"100", "", "100", ""},
+		{"100", "data wei", "100", "data wei"},
+	}
+
+	for _, test := range tests {
+		amount, rest := SplitAmount(test.amount, test.rest)
+		if amount != test.expAmount || rest != test.expRest {
+			t.Errorf("%s %s: expected '%s' '%s', got '%s' '%s'", test.amount, test.rest, test.expAmount, test.expRest, amount, rest)
+		}
+	}
+}
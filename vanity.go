@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/keys"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Interval between progress reports of the vanity search
+const vanityReportInterval = 2 * time.Second
+
+// Expected times beyond this are reported as such, they'd overflow a
+// time.Duration anyway
+const maxVanityETA = 100 * 365 * 24 * time.Hour
+
+func vanityETA(seconds float64) string {
+	if seconds >= maxVanityETA.Seconds() {
+		return "over 100 years"
+	}
+
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// Searches a key pair whose address matches the pattern using all CPUs. The
+// search can be cancelled with an interrupt. The key found is encrypted and
+// either added to the key ring or, if a file is given, written to a key file.
+func GenerateVanityKey(keyRing *ethkeys.KeyRing, prefix, suffix, file string) error {
+	pattern, err := ethkeys.NewVanityPattern(prefix, suffix)
+	if err != nil {
+		return err
+	}
+
+	// Interrupts keep coming after the first one has cancelled the search
+	quit := make(chan struct{})
+	var once sync.Once
+	HandleInterrupts(func(sig os.Signal) {
+		once.Do(func() { close(quit) })
+	})
+
+	workers := runtime.NumCPU()
+	fmt.Printf("Searching an address matching %v on %d workers (~%.0f keys, Ctrl-C to cancel)\n", pattern, workers, pattern.Difficulty())
+
+	var attempts uint64
+	done := make(chan struct{})
+	go func() {
+		start := time.Now()
+		ticker := time.NewTicker(vanityReportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				tried := atomic.LoadUint64(&attempts)
+				if tried == 0 {
+					continue
+				}
+				rate := float64(tried) / time.Since(start).Seconds()
+				// The search is memoryless, the expected time left doesn't
+				// depend on the number of keys tried so far
+				eta := vanityETA(pattern.Difficulty() / rate)
+				fmt.Printf("%d keys tried, %.0f keys/s, expected time %s\n", tried, rate, eta)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	key := ethkeys.SearchVanity(pattern, workers, quit, &attempts)
+	close(done)
+	if key == nil {
+		return ErrAborted
+	}
+
+	fmt.Printf("Found address %x after %d keys\n", key.Address(), atomic.LoadUint64(&attempts))
+	if len(file) == 0 && !Confirm("Store the key in the key ring?") {
+		return ErrAborted
+	}
+
+	pass, err := ReadNewPassphrase()
+	if err != nil {
+		return err
+	}
+
+	acc, err := ethkeys.NewAccount(fmt.Sprintf("vanity-%x", key.Address()[:4]), key, pass)
+	if err != nil {
+		return err
+	}
+
+	if len(file) > 0 {
+		if err := ExportKeyFile(acc, file); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote the encrypted key of %x to %s, -importfile adds it to a key ring\n", acc.Address, file)
+
+		return nil
+	}
+
+	if err := keyRing.Add(acc); err != nil {
+		return err
+	}
+	fmt.Printf("created account '%s' %x\n", acc.Label, acc.Address)
+
+	return nil
+}
@@ -58,6 +58,10 @@ ApplicationWindow {
 		id: blockModel
 	}
 
+	property var accountModel: ListModel {
+		id: accountModel
+	}
+
 	function setView(view) {
 		networkView.visible = false
 		historyView.visible = false
@@ -122,6 +126,10 @@ ApplicationWindow {
 			id: txModel
 		}
 
+		property var watchModel: ListModel {
+			id: watchModel
+		}
+
 		Rectangle {
 			id: historyView
 			property var title: "Transactions"
@@ -129,11 +137,61 @@ ApplicationWindow {
 			anchors.left: menu.right
 			anchors.bottom: parent.bottom
 			anchors.top: parent.top
+
+			TableView {
+				id: watchTableView
+				anchors.top: parent.top
+				anchors.left: parent.left
+				anchors.right: parent.right
+				height: 120
+				TableViewColumn{ role: "label" ; title: "Watch-only" ; width: 100 }
+				TableViewColumn{ role: "address" ; title: "Address" ; width: 330 }
+				TableViewColumn{ role: "value" ; title: "Balance (not spendable)" ; width: 150 }
+
+				model: watchModel
+			}
+
+			RowLayout {
+				id: watchForm
+				anchors.top: watchTableView.bottom
+				anchors.left: parent.left
+				anchors.right: parent.right
+				anchors.margins: 5
+
+				TextField {
+					id: watchAddr
+					placeholderText: "Address to watch"
+					Layout.fillWidth: true
+				}
+				TextField {
+					id: watchLabel
+					placeholderText: "Label"
+				}
+				Button {
+					text: "Watch"
+					onClicked: {
+						var err = eth.watchAddr(watchAddr.text, watchLabel.text)
+						if(err.length == 0) {
+							addWatch({label: watchLabel.text, address: watchAddr.text, value: eth.getBalance(watchAddr.text)})
+							watchAddr.text = ""
+							watchLabel.text = ""
+						} else {
+							console.log(err)
+						}
+					}
+				}
+			}
+
 			TableView {
 				id: txTableView
-				anchors.fill: parent
+				anchors.top: watchForm.bottom
+				anchors.topMargin: 5
+				anchors.left: parent.left
+				anchors.right: parent.right
+				anchors.bottom: parent.bottom
 				TableViewColumn{ role: "value" ; title: "Value" ; width: 100 }
-				TableViewColumn{ role: "address" ; title: "Address" ; width: 430 }
+				TableViewColumn{ role: "address" ; title: "Address" ; width: 330 }
+				TableViewColumn{ role: "account" ; title: "Account" ; width: 150 }
 
 				model: txModel
 			}
@@ -155,6 +213,12 @@ ApplicationWindow {
 				anchors.top: parent.top
 				anchors.leftMargin: 5
 				anchors.topMargin: 5
+				ComboBox {
+					id: txAccount
+					Layout.fillWidth: true
+					model: accountModel
+				}
+
 				TextField {
 					id: txAmount
 					width: 200
@@ -177,12 +241,47 @@ ApplicationWindow {
 					width: parent.width /2 
 				}
 
+				RowLayout {
+					TextField {
+						id: txPassphrase
+						placeholderText: "Passphrase"
+						echoMode: TextInput.Password
+						Layout.fillWidth: true
+					}
+					TextField {
+						id: txUnlockTime
+						placeholderText: "Seconds (empty for one tx)"
+						validator: IntValidator { bottom: 0 }
+					}
+					Button {
+						text: "Unlock"
+						onClicked: {
+							var account = accountModel.get(txAccount.currentIndex).address
+							var err = eth.unlock(account, txPassphrase.text, parseInt(txUnlockTime.text) || 0)
+							txPassphrase.text = ""
+							txStatus.text = err.length > 0 ? err : "Unlocked"
+						}
+					}
+					Button {
+						text: "Lock"
+						onClicked: {
+							eth.lock(accountModel.get(txAccount.currentIndex).address)
+							txStatus.text = "Locked"
+						}
+					}
+				}
+
 				Button {
 					text: "Send"
 					onClicked: {
-						console.log(eth.createTx(txReceiver.text, txAmount.text, codeView.text))
+						txStatus.text = eth.createTx(accountModel.get(txAccount.currentIndex).address, txReceiver.text, txAmount.text, codeView.text)
+						console.log(txStatus.text)
 					}
 				}
+
+				Label {
+					id: txStatus
+				}
 			}
 		}
 
@@ -351,7 +450,23 @@ ApplicationWindow {
 	}
 
 	function addTx(tx) {
-		txModel.insert(0, {hash: tx.hash, address: tx.address, value: tx.value})
+		txModel.insert(0, {hash: tx.hash, address: tx.address, value: tx.value, account: tx.account})
+	}
+
+	function addWatch(watch) {
+		watchModel.append({label: watch.label, address: watch.address, value: watch.value})
+	}
+
+	function setWatchValue(address, value) {
+		for(var i = 0; i < watchModel.count; i++) {
+			if(watchModel.get(i).address == address) {
+				watchModel.setProperty(i, "value", value)
+			}
+		}
+	}
+
+	function addAccount(account) {
+		accountModel.append({text: account.label + " (" + account.address + ")", address: account.address})
 	}
 
 	function addBlock(block) {
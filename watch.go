@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ethereum/eth-go"
+	"github.com/ethereum/eth-go/ethchain"
+	"github.com/ethereum/eth-go/ethutil"
+	"github.com/ethereum/go-ethereum/keys"
+)
+
+// Returns the current state of the watched addresses
+func NewWatchResults(ethereum *eth.Ethereum, watchList *ethkeys.WatchList) WatchResults {
+	list := WatchResults{}
+	for _, w := range watchList.Entries() {
+		list = append(list, NewAddrResult(ethereum, w.Address, w.Label))
+	}
+
+	return list
+}
+
+// Reports incoming and outgoing transactions of watch-only addresses
+func ReportWatchedTxs(ethereum *eth.Ethereum, watchList *ethkeys.WatchList) {
+	txChan := make(chan ethchain.TxMsg, 1)
+	ethereum.TxPool.Subscribe(txChan)
+
+	for txMsg := range txChan {
+		tx := txMsg.Tx
+		state := "pending"
+		if txMsg.Type != ethchain.TxPre {
+			state = "confirmed"
+		}
+
+		if w := watchList.Get(tx.Sender()); w != nil {
+			fmt.Printf("\nwatch: %s outgoing %v to %x (%s, %x)\n", w.Label, ethutil.CurrencyToString(tx.Value), tx.Recipient, state, tx.Hash())
+		}
+
+		if w := watchList.Get(tx.Recipient); w != nil {
+			fmt.Printf("\nwatch: %s incoming %v from %x (%s, %x)\n", w.Label, ethutil.CurrencyToString(tx.Value), tx.Sender(), state, tx.Hash())
+		}
+	}
+}